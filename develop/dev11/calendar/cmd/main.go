@@ -1,71 +1,223 @@
 package calendar
 
 import (
+	"context"
+	"dev11/calendar/internal/bus"
 	"dev11/calendar/internal/config"
 	"dev11/calendar/internal/handler"
+	"dev11/calendar/internal/idempotency"
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/metrics"
+	"dev11/calendar/internal/middleware"
+	"dev11/calendar/internal/reminder"
 	"dev11/calendar/internal/repository"
 	"dev11/calendar/internal/service"
+	"dev11/calendar/internal/snapshot"
 	"dev11/calendar/internal/storage"
-	"log"
+	"dev11/calendar/internal/webhook"
+	"dev11/calendar/pkg/logger"
+	"errors"
+	"flag"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
-func Start() {
-	// Получение конфигураций
-	conf := config.GetConfig()
+// Запуск сервиса календаря. Блокируется до сигнала об окончании работы, после которого
+// дожидается завершения обрабатываемых запросов и сохраняет события.
+// Возвращает ошибку, если сервис не удалось запустить
+func Start() error {
+	// Получение конфигурации из файла, окружения и флагов поверх значений по умолчанию
+	conf, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Логгер со структурированными записями. Уровень уже проверен при загрузке конфигурации
+	level, _ := logger.ParseLevel(conf.LogLevel)
+	logger := logger.New(os.Stdout, level)
+
+	// Реестр метрик и метрики HTTP-запросов
+	registry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+
+	// Хранилище событий выбранного в конфигурации типа со счетчиком записей
+	eventStorage, err := storage.New(conf.StorageType, conf.StorageFilePath)
+	if err != nil {
+		logger.Error("error while init event storage", "err", err)
+		return fmt.Errorf("init event storage: %w", err)
+	}
+	defer eventStorage.Close()
+	eventStorage = storage.NewInstrumented(eventStorage, metrics.NewStorageWrites(registry))
 
-	// Хранилище событий
-	storage := storage.NewEventStorage(conf.StorageFilePath)
+	// Журнал операций над событиями
+	journal, err := journal.NewJournal(conf.JournalFilePath, logger)
+	if err != nil {
+		logger.Error("error while init event journal", "err", err)
+		return fmt.Errorf("init event journal: %w", err)
+	}
+	defer journal.Close()
 
 	// Репозиторий событий
-	repo, err := repository.NewEventRepository(storage)
+	repo, err := repository.NewEventRepository(eventStorage, journal, logger)
 	if err != nil {
-		log.Printf("error while init event repository: %v", err)
-		panic(err)
+		logger.Error("error while init event repository", "err", err)
+		return fmt.Errorf("init event repository: %w", err)
 	}
 
+	// Количество хранимых событий
+	metrics.RegisterEventCount(registry, repo.Count)
+
+	// Шина изменений событий для потока изменений и вебхуков
+	eventBus := bus.NewBus()
+
 	// Сервис событий (бизнес логика)
-	service := service.NewEventService(repo)
+	service := service.NewEventService(repo, eventBus, logger, conf.MaxRangeDays)
 
 	// Перед выходом из программы выполняется сохранение событий в хранилище
 	defer func() {
-		err = service.SaveEvents()
-		if err != nil {
-			log.Printf("error while saving events: %v", err)
+		if err := service.SaveEvents(); err != nil {
+			logger.Error("error while saving events", "err", err)
 		}
 	}()
 
+	// Фоновое сохранение снимков. Останавливается до финального сохранения при выходе
+	saver := snapshot.NewSaver(service, conf.SnapshotInterval, conf.SnapshotMaxMutations,
+		time.Duration(conf.TrashRetentionDays)*24*time.Hour, logger)
+	saverCtx, stopSaver := context.WithCancel(context.Background())
+	saverDone := make(chan struct{})
+	go func() {
+		defer close(saverDone)
+		saver.Run(saverCtx)
+	}()
+	defer func() {
+		stopSaver()
+		<-saverDone
+	}()
+
+	// Получатель напоминаний
+	notifier, err := reminder.NewNotifier(conf.ReminderNotifier, conf.ReminderWebhookURL, os.Stdout)
+	if err != nil {
+		logger.Error("error while init reminder notifier", "err", err)
+		return fmt.Errorf("init reminder notifier: %w", err)
+	}
+
+	// Планировщик напоминаний
+	scheduler, err := reminder.NewScheduler(repo, notifier, conf.ReminderInterval, conf.RemindersFilePath, logger)
+	if err != nil {
+		logger.Error("error while init reminder scheduler", "err", err)
+		return fmt.Errorf("init reminder scheduler: %w", err)
+	}
+
+	// Фоновая отправка напоминаний
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Run(schedulerCtx)
+	}()
+	defer func() {
+		stopScheduler()
+		<-schedulerDone
+	}()
+
+	// Фоновая рассылка изменений на вебхуки
+	dispatcher := webhook.NewDispatcher(eventBus, conf.WebhookURLs, []byte(conf.WebhookSecret),
+		conf.WebhookMaxAttempts, conf.WebhookBackoff, logger)
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		dispatcher.Run(dispatcherCtx)
+	}()
+	defer func() {
+		stopDispatcher()
+		<-dispatcherDone
+	}()
+
 	// Хэндлер событий
-	handler := handler.NewEventHandler(service)
+	eventHandler := handler.NewEventHandler(service, logger, httpMetrics, middleware.Auth([]byte(conf.AuthSecret)),
+		middleware.Idempotency(idempotency.NewStore(conf.IdempotencyTTL, conf.IdempotencyMaxKeys)))
+
+	// Хэндлер состояния снимков
+	snapshotHandler := handler.NewSnapshotHandler(saver, logger, httpMetrics)
+
+	// Хэндлер метрик
+	metricsHandler := handler.NewMetricsHandler(registry, logger)
+
+	// Хэндлер документа OpenAPI
+	openAPIHandler := handler.NewOpenAPIHandler(logger)
+
+	// Хэндлер потока изменений событий
+	streamHandler := handler.NewStreamHandler(eventBus, logger, httpMetrics, middleware.Auth([]byte(conf.AuthSecret)))
 
 	// Роутер сервера
 	mux := http.NewServeMux()
 
-	// Регистрация методов событий в роутере
-	handler.Register(mux)
+	// Регистрация методов в роутере
+	eventHandler.Register(mux)
+	snapshotHandler.Register(mux)
+	metricsHandler.Register(mux)
+	openAPIHandler.Register(mux)
+	streamHandler.Register(mux)
 
 	// Сервер
 	srv := &http.Server{
-		Addr:    net.JoinHostPort(conf.Host, conf.Port),
-		Handler: mux,
+		Addr:         net.JoinHostPort(conf.Host, conf.Port),
+		Handler:      mux,
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+		IdleTimeout:  conf.IdleTimeout,
+	}
+
+	// Потоки изменений не завершаются сами, поэтому закрываются в начале остановки сервера
+	srv.RegisterOnShutdown(streamHandler.Close)
+
+	// Порт занимается до запуска сервера, чтобы ошибка прослушивания вернулась из Start
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		logger.Error("error while listening", "addr", srv.Addr, "err", err)
+		return fmt.Errorf("listen on %s: %w", srv.Addr, err)
 	}
 
 	// Запуск сервера
+	serveErr := make(chan error, 1)
 	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Printf("error while init event repository: %v", err)
-			panic(err)
-		}
+		serveErr <- srv.Serve(listener)
 	}()
+	logger.Info("server started", "addr", listener.Addr().String())
 
 	// Отлов сигналов об окончании работы
 	osSignal := make(chan os.Signal, 1)
 	signal.Notify(osSignal, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	defer signal.Stop(osSignal)
+
+	// Работа завершается по сигналу или при остановке сервера из-за ошибки
+	select {
+	case sig := <-osSignal:
+		logger.Info("shutting down", "signal", sig.String())
+	case err := <-serveErr:
+		logger.Error("error while serving http", "err", err)
+		return fmt.Errorf("serve http: %w", err)
+	}
+
+	// Новые соединения больше не принимаются, обрабатываемые запросы завершаются до истечения
+	// таймаута остановки из конфигурации. Оставшиеся после этого соединения закрываются принудительно.
+	// События сохраняются отложенными вызовами уже после остановки сервера
+	ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warn("graceful shutdown timed out, closing connections", "timeout", conf.ShutdownTimeout, "err", err)
+		srv.Close()
+	}
 
-	// При поступлении сигнала программа завершается
-	<-osSignal
+	return nil
 }
@@ -0,0 +1,179 @@
+package bus
+
+import (
+	"dev11/calendar/internal/model"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Типы изменений событий
+const (
+	ChangeCreated  = "created"
+	ChangeUpdated  = "updated"
+	ChangeDeleted  = "deleted"
+	ChangeRestored = "restored"
+)
+
+const (
+	// Количество последних изменений, повторяемых подписчику, который переподключился
+	historySize = 1024
+	// Количество изменений, ожидающих чтения подписчиком. При переполнении подписка закрывается
+	subscriberBuffer = 256
+)
+
+// Ошибка подписки на закрытую шину
+var ErrClosed = errors.New("bus is closed")
+
+// Изменение события. ID - возрастающий номер изменения в шине.
+// Для изменения отдельного повторения серии EventID - ID серии, а Occurrence - дата повторения.
+// Event - состояние события после изменения, у удаленного события отсутствует.
+// UserID - организатор события, AttendeeIDs - пользователи, которым изменение видно наравне с ним
+// (участники события); в сообщение AttendeeIDs не входит
+type Change struct {
+	ID          uint64       `json:"id"`
+	Type        string       `json:"type"`
+	EventID     int          `json:"event_id"`
+	UserID      int          `json:"user_id"`
+	Occurrence  string       `json:"occurrence,omitempty"`
+	Event       *model.Event `json:"event,omitempty"`
+	At          time.Time    `json:"at"`
+	AttendeeIDs []int        `json:"-"`
+}
+
+// Признак того, что изменение видно пользователю userID - организатору или участнику события
+func (c Change) VisibleTo(userID int) bool {
+	if c.UserID == userID {
+		return true
+	}
+
+	for _, attendeeID := range c.AttendeeIDs {
+		if attendeeID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Шина изменений в памяти процесса. Публикация не блокируется медленными подписчиками:
+// подписка, не успевающая читать изменения, закрывается, и подписчик может подписаться снова
+// с ID последнего полученного изменения, чтобы получить пропущенные изменения из истории
+type eventBus struct {
+	mu      sync.Mutex
+	lastID  uint64
+	history []Change
+	subs    map[*subscription]struct{}
+	closed  bool
+}
+
+// Конструктор шины изменений
+func NewBus() IBus {
+	return &eventBus{
+		subs: map[*subscription]struct{}{},
+	}
+}
+
+// Публикация изменения change подписчикам. Номер и время изменения назначаются шиной
+func (b *eventBus) Publish(change Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	change.ID = b.lastID
+	if change.At.IsZero() {
+		change.At = time.Now().UTC()
+	}
+
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:historySize-1]
+	}
+	b.history = append(b.history, change)
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(change) {
+			continue
+		}
+
+		select {
+		case sub.changes <- change:
+		default:
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// Подписка на изменения, для которых filter возвращает true (nil - на все изменения).
+// Ненулевой lastID - ID последнего полученного изменения: изменения после него,
+// сохранившиеся в истории, передаются подписке первыми
+func (b *eventBus) Subscribe(lastID uint64, filter func(Change) bool) (ISubscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	var missed []Change
+	if lastID > 0 {
+		for _, change := range b.history {
+			if change.ID > lastID && (filter == nil || filter(change)) {
+				missed = append(missed, change)
+			}
+		}
+	}
+
+	sub := &subscription{
+		bus:     b,
+		filter:  filter,
+		changes: make(chan Change, subscriberBuffer+len(missed)),
+	}
+	for _, change := range missed {
+		sub.changes <- change
+	}
+	b.subs[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Закрытие шины и всех подписок
+func (b *eventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.unsubscribe(sub)
+	}
+}
+
+// Удаление подписки sub с закрытием ее канала. Вызывается под блокировкой шины
+func (b *eventBus) unsubscribe(sub *subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+
+	delete(b.subs, sub)
+	close(sub.changes)
+}
+
+// Подписка на изменения шины
+type subscription struct {
+	bus     *eventBus
+	filter  func(Change) bool
+	changes chan Change
+}
+
+// Канал изменений. Закрывается при отмене подписки, закрытии шины или переполнении
+func (s *subscription) Changes() <-chan Change {
+	return s.changes
+}
+
+// Отмена подписки
+func (s *subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.unsubscribe(s)
+}
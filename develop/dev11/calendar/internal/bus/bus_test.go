@@ -0,0 +1,120 @@
+package bus
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ID изменений, уже находящихся в канале подписки sub
+func pending(sub ISubscription) []uint64 {
+	ids := []uint64{}
+	for {
+		select {
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, change.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+// Фильтр изменений пользователя userID
+func ofUser(userID int) func(Change) bool {
+	return func(change Change) bool { return change.UserID == userID }
+}
+
+func Test_eventBus_Publish(t *testing.T) {
+	b := NewBus()
+
+	all, _ := b.Subscribe(0, nil)
+	first, _ := b.Subscribe(0, ofUser(1))
+
+	b.Publish(Change{Type: ChangeCreated, EventID: 1, UserID: 1})
+	b.Publish(Change{Type: ChangeCreated, EventID: 2, UserID: 2})
+	b.Publish(Change{Type: ChangeDeleted, EventID: 1, UserID: 1})
+
+	if got, want := pending(all), []uint64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all changes = %v, want %v", got, want)
+	}
+	if got, want := pending(first), []uint64{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes of user 1 = %v, want %v", got, want)
+	}
+
+	// Отмененная подписка больше не получает изменений
+	first.Close()
+	b.Publish(Change{Type: ChangeUpdated, EventID: 1, UserID: 1})
+	if _, ok := <-first.Changes(); ok {
+		t.Error("expected closed subscription")
+	}
+}
+
+func Test_Change_VisibleTo(t *testing.T) {
+	change := Change{Type: ChangeDeleted, EventID: 1, UserID: 1, AttendeeIDs: []int{2, 3}}
+
+	for userID, want := range map[int]bool{1: true, 2: true, 3: true, 4: false} {
+		if got := change.VisibleTo(userID); got != want {
+			t.Errorf("VisibleTo(%d) = %v, want %v", userID, got, want)
+		}
+	}
+}
+
+func Test_eventBus_Subscribe_replay(t *testing.T) {
+	b := NewBus()
+	for i := 1; i <= 4; i++ {
+		b.Publish(Change{Type: ChangeCreated, EventID: i, UserID: i % 2})
+	}
+
+	// Переподключение после изменения 1 повторяет последующие изменения пользователя
+	sub, _ := b.Subscribe(1, ofUser(1))
+	b.Publish(Change{Type: ChangeUpdated, EventID: 5, UserID: 1})
+
+	if got, want := pending(sub), []uint64{3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func Test_eventBus_overflow(t *testing.T) {
+	b := NewBus()
+
+	slow, _ := b.Subscribe(0, nil)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(Change{Type: ChangeCreated, EventID: i + 1})
+	}
+
+	// Переполненная подписка закрывается после полученных изменений
+	received := pending(slow)
+	if len(received) != subscriberBuffer {
+		t.Fatalf("received %d changes, want %d", len(received), subscriberBuffer)
+	}
+	if _, ok := <-slow.Changes(); ok {
+		t.Fatal("expected overflowed subscription to be closed")
+	}
+
+	// Новая подписка с последнего полученного изменения получает пропущенное
+	resumed, _ := b.Subscribe(received[len(received)-1], nil)
+	if got, want := pending(resumed), []uint64{subscriberBuffer + 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missed changes = %v, want %v", got, want)
+	}
+}
+
+func Test_eventBus_Close(t *testing.T) {
+	b := NewBus()
+	sub, _ := b.Subscribe(0, nil)
+
+	b.Close()
+
+	if _, ok := <-sub.Changes(); ok {
+		t.Error("expected subscription to be closed with the bus")
+	}
+	if _, err := b.Subscribe(0, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	// Публикация в закрытую шину и повторная отмена подписки допустимы
+	b.Publish(Change{Type: ChangeCreated, EventID: 1})
+	sub.Close()
+}
@@ -0,0 +1,14 @@
+package bus
+
+// Шина изменений событий
+type IBus interface {
+	Publish(change Change)
+	Subscribe(lastID uint64, filter func(Change) bool) (ISubscription, error)
+	Close()
+}
+
+// Подписка на изменения
+type ISubscription interface {
+	Changes() <-chan Change
+	Close()
+}
@@ -1,17 +1,192 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"dev11/calendar/internal/reminder"
+	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
+)
+
 // Конфигурация приложения
 type Config struct {
+	// Тип хранилища: "file" или "bolt"
+	StorageType     string
 	StorageFilePath string
+	// Файл журнала операций, применяемого поверх снимка хранилища
+	JournalFilePath string
 	Host            string
 	Port            string
+	// Таймауты HTTP-сервера: чтение запроса, запись ответа, простой keep-alive соединения
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// Время на завершение обрабатываемых запросов при остановке сервиса
+	ShutdownTimeout time.Duration
+	// Период фонового снимка событий в хранилище
+	SnapshotInterval time.Duration
+	// Количество несохраненных изменений, после которого снимок делается досрочно
+	SnapshotMaxMutations int
+	// Срок хранения удаленных событий в днях, после которого они окончательно удаляются
+	// ежечасной очисткой корзины. Ноль хранит удаленные события бессрочно
+	TrashRetentionDays int
+	// Наименьший уровень записей лога: debug, info, warn, error
+	LogLevel string
+	// Секрет для проверки подписи токенов пользователей. Значения по умолчанию нет:
+	// без явно заданного секрета сервер не запускается
+	AuthSecret string
+	// Период просмотра событий планировщиком напоминаний
+	ReminderInterval time.Duration
+	// Получатель напоминаний: "log" или "webhook"
+	ReminderNotifier   string
+	ReminderWebhookURL string
+	// Файл отправленных напоминаний, чтобы не повторять их после перезапуска
+	RemindersFilePath string
+	// Адреса вебхуков, получающих изменения событий. Без адресов изменения не рассылаются
+	WebhookURLs []string
+	// Секрет подписи запросов к вебхукам
+	WebhookSecret string
+	// Количество попыток доставки изменения на вебхук
+	WebhookMaxAttempts int
+	// Пауза перед повтором доставки, удваивающаяся после каждой неудачной попытки
+	WebhookBackoff time.Duration
+	// Срок хранения ключей идемпотентности и ответов на запросы с ними
+	IdempotencyTTL time.Duration
+	// Наибольшее количество хранимых ключей идемпотентности. При заполнении вытесняются
+	// ключи с самыми ранними ответами
+	IdempotencyMaxKeys int
+	// Наибольшая ширина запрашиваемого периода в днях. Более широкие периоды отклоняются,
+	// чтобы один запрос не разворачивал серии на века вперед
+	MaxRangeDays int
 }
 
-// Геттер конфигурации
-func GetConfig() Config {
+// Конфигурация по умолчанию
+func Default() Config {
 	return Config{
-		StorageFilePath: "storage/data.json",
-		Host:            "127.0.0.1",
-		Port:            "8081",
+		StorageType:          storage.TypeFile,
+		StorageFilePath:      "storage/data.json",
+		JournalFilePath:      "storage/journal.log",
+		Host:                 "127.0.0.1",
+		Port:                 "8081",
+		ReadTimeout:          10 * time.Second,
+		WriteTimeout:         30 * time.Second,
+		IdleTimeout:          2 * time.Minute,
+		ShutdownTimeout:      15 * time.Second,
+		SnapshotInterval:     30 * time.Second,
+		SnapshotMaxMutations: 100,
+		TrashRetentionDays:   30,
+		LogLevel:             "info",
+		ReminderInterval:     30 * time.Second,
+		ReminderNotifier:     reminder.NotifierLog,
+		RemindersFilePath:    "storage/reminders.json",
+		WebhookMaxAttempts:   5,
+		WebhookBackoff:       time.Second,
+		IdempotencyTTL:       24 * time.Hour,
+		IdempotencyMaxKeys:   100000,
+		MaxRangeDays:         366,
+	}
+}
+
+// Проверка конфигурации. Возвращает все найденные ошибки сразу
+func (c Config) Validate() error {
+	var errs []error
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	check("storage_type", oneOf(c.StorageType, storage.TypeFile, storage.TypeBolt))
+	check("storage_file_path", notEmpty(c.StorageFilePath))
+	check("journal_file_path", notEmpty(c.JournalFilePath))
+	check("port", validatePort(c.Port))
+	check("read_timeout", positive(c.ReadTimeout))
+	check("write_timeout", positive(c.WriteTimeout))
+	check("idle_timeout", positive(c.IdleTimeout))
+	check("shutdown_timeout", positive(c.ShutdownTimeout))
+	check("snapshot_interval", positive(c.SnapshotInterval))
+	if c.SnapshotMaxMutations <= 0 {
+		check("snapshot_max_mutations", errors.New("should be positive"))
+	}
+	if c.TrashRetentionDays < 0 {
+		check("trash_retention_days", errors.New("should not be negative"))
+	}
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		check("log_level", oneOf(c.LogLevel, "debug", "info", "warn", "error"))
+	}
+	check("auth_secret", notEmpty(c.AuthSecret))
+	check("reminder_interval", positive(c.ReminderInterval))
+	check("reminder_notifier", oneOf(c.ReminderNotifier, reminder.NotifierLog, reminder.NotifierWebhook))
+	if c.ReminderNotifier == reminder.NotifierWebhook {
+		check("reminder_webhook_url", validateURL(c.ReminderWebhookURL))
+	}
+	check("reminders_file_path", notEmpty(c.RemindersFilePath))
+	for _, url := range c.WebhookURLs {
+		check("webhook_urls", validateURL(url))
+	}
+	if len(c.WebhookURLs) > 0 {
+		check("webhook_secret", notEmpty(c.WebhookSecret))
+	}
+	if c.WebhookMaxAttempts <= 0 {
+		check("webhook_max_attempts", errors.New("should be positive"))
+	}
+	check("webhook_backoff", positive(c.WebhookBackoff))
+	check("idempotency_ttl", positive(c.IdempotencyTTL))
+	if c.IdempotencyMaxKeys <= 0 {
+		check("idempotency_max_keys", errors.New("should be positive"))
+	}
+	if c.MaxRangeDays <= 0 {
+		check("max_range_days", errors.New("should be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Проверка, что значение value входит в allowed
+func oneOf(value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("should be one of %q, got %q", allowed, value)
+}
+
+// Проверка непустого значения
+func notEmpty(value string) error {
+	if value == "" {
+		return errors.New("should not be empty")
+	}
+	return nil
+}
+
+// Проверка положительной длительности
+func positive(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("should be a positive duration, got %s", d)
+	}
+	return nil
+}
+
+// Проверка номера порта
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("should be an integer from 1 to 65535, got %q", port)
+	}
+	return nil
+}
+
+// Проверка абсолютного http(s) адреса
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("should be an absolute http or https URL, got %q", value)
 	}
+	return nil
 }
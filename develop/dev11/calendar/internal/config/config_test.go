@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Переменные окружения из мапы
+func envFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func Test_Load_precedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	data := `{"port": 9000, "host": "0.0.0.0", "snapshot_interval": "1m", "log_level": "debug"}`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	env := envFrom(map[string]string{
+		"CALENDAR_CONFIG":       file,
+		"CALENDAR_PORT":         "9001",
+		"CALENDAR_STORAGE_TYPE": "bolt",
+		"CALENDAR_AUTH_SECRET":  "secret",
+	})
+
+	conf, err := Load([]string{"-port", "9002"}, env)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	// Флаг важнее окружения, окружение важнее файла, файл важнее значений по умолчанию
+	if conf.Port != "9002" {
+		t.Errorf("expected port from flag, got %q", conf.Port)
+	}
+	if conf.StorageType != "bolt" {
+		t.Errorf("expected storage type from env, got %q", conf.StorageType)
+	}
+	if conf.Host != "0.0.0.0" || conf.SnapshotInterval != time.Minute || conf.LogLevel != "debug" {
+		t.Errorf("expected host, snapshot interval and log level from file, got %+v", conf)
+	}
+	if conf.JournalFilePath != Default().JournalFilePath {
+		t.Errorf("expected default journal path, got %q", conf.JournalFilePath)
+	}
+}
+
+func Test_Load_errors(t *testing.T) {
+	noEnv := envFrom(nil)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		contains []string
+	}{
+		{
+			name:     "bad env duration",
+			env:      map[string]string{"CALENDAR_READ_TIMEOUT": "soon"},
+			contains: []string{"env CALENDAR_READ_TIMEOUT", "should be a duration"},
+		},
+		{
+			name:     "all validation errors",
+			args:     []string{"-port", "70000", "-storage-type", "sql", "-reminder-notifier", "webhook", "-max-range-days", "0"},
+			contains: []string{"port: should be an integer from 1 to 65535", "storage_type: should be one of", "reminder_webhook_url", "max_range_days: should be positive"},
+		},
+		{
+			name:     "webhooks without secret",
+			env:      map[string]string{"CALENDAR_WEBHOOK_URLS": "http://localhost:9000/hook, ftp://example.com"},
+			contains: []string{`webhook_urls: should be an absolute http or https URL, got "ftp://example.com"`, "webhook_secret: should not be empty"},
+		},
+		{
+			name:     "no auth secret by default",
+			contains: []string{"auth_secret: should not be empty"},
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"-colour", "red"},
+			contains: []string{"flag provided but not defined"},
+		},
+		{
+			name:     "missing file",
+			args:     []string{"-config", "/nonexistent/config.json"},
+			contains: []string{"reading config file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := noEnv
+			if tt.env != nil {
+				env = envFrom(tt.env)
+			}
+
+			_, err := Load(tt.args, env)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("expected error to contain %q, got %q", part, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_Load_unknownFileSetting(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"prot": 1}`), 0644); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	_, err := Load([]string{"-config", file}, envFrom(map[string]string{"CALENDAR_AUTH_SECRET": "secret"}))
+	if err == nil || !strings.Contains(err.Error(), `unknown setting "prot"`) {
+		t.Errorf("expected unknown setting error, got %v", err)
+	}
+}
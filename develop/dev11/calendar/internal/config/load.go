@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Префикс переменных окружения
+	envPrefix = "CALENDAR_"
+	// Настройка с путем к файлу конфигурации
+	configSetting = "config"
+)
+
+// Настройка конфигурации. Ключ name используется в файле (storage_type),
+// в переменной окружения (CALENDAR_STORAGE_TYPE) и во флаге (-storage-type)
+type setting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+// Все настройки конфигурации
+var settings = []setting{
+	stringSetting("storage_type", "storage backend: file or bolt", func(c *Config) *string { return &c.StorageType }),
+	stringSetting("storage_file_path", "path to the storage file", func(c *Config) *string { return &c.StorageFilePath }),
+	stringSetting("journal_file_path", "path to the operation journal", func(c *Config) *string { return &c.JournalFilePath }),
+	stringSetting("host", "address to listen on", func(c *Config) *string { return &c.Host }),
+	stringSetting("port", "port to listen on", func(c *Config) *string { return &c.Port }),
+	durationSetting("read_timeout", "maximum duration for reading a request", func(c *Config) *time.Duration { return &c.ReadTimeout }),
+	durationSetting("write_timeout", "maximum duration for writing a response", func(c *Config) *time.Duration { return &c.WriteTimeout }),
+	durationSetting("idle_timeout", "maximum idle time of a keep-alive connection", func(c *Config) *time.Duration { return &c.IdleTimeout }),
+	durationSetting("shutdown_timeout", "time to drain in-flight requests on shutdown", func(c *Config) *time.Duration { return &c.ShutdownTimeout }),
+	durationSetting("snapshot_interval", "period of background snapshots", func(c *Config) *time.Duration { return &c.SnapshotInterval }),
+	intSetting("snapshot_max_mutations", "number of unsaved changes that triggers an early snapshot", func(c *Config) *int { return &c.SnapshotMaxMutations }),
+	intSetting("trash_retention_days", "days to keep deleted events before purging them, 0 keeps them forever", func(c *Config) *int { return &c.TrashRetentionDays }),
+	stringSetting("log_level", "minimum log level: debug, info, warn or error", func(c *Config) *string { return &c.LogLevel }),
+	stringSetting("auth_secret", "secret for signing user tokens", func(c *Config) *string { return &c.AuthSecret }),
+	durationSetting("reminder_interval", "period of reminder scans", func(c *Config) *time.Duration { return &c.ReminderInterval }),
+	stringSetting("reminder_notifier", "reminder notifier: log or webhook", func(c *Config) *string { return &c.ReminderNotifier }),
+	stringSetting("reminder_webhook_url", "URL for the webhook reminder notifier", func(c *Config) *string { return &c.ReminderWebhookURL }),
+	stringSetting("reminders_file_path", "path to the file with fired reminders", func(c *Config) *string { return &c.RemindersFilePath }),
+	listSetting("webhook_urls", "comma-separated URLs of webhooks receiving event changes", func(c *Config) *[]string { return &c.WebhookURLs }),
+	stringSetting("webhook_secret", "secret for signing webhook requests", func(c *Config) *string { return &c.WebhookSecret }),
+	intSetting("webhook_max_attempts", "number of attempts to deliver a change to a webhook", func(c *Config) *int { return &c.WebhookMaxAttempts }),
+	durationSetting("webhook_backoff", "delay before the first retry of a webhook delivery, doubled after each retry", func(c *Config) *time.Duration { return &c.WebhookBackoff }),
+	durationSetting("idempotency_ttl", "time to keep idempotency keys and replay responses to retried requests", func(c *Config) *time.Duration { return &c.IdempotencyTTL }),
+	intSetting("idempotency_max_keys", "maximum number of kept idempotency keys, the oldest answered keys are evicted first", func(c *Config) *int { return &c.IdempotencyMaxKeys }),
+	intSetting("max_range_days", "maximum width of a requested period in days", func(c *Config) *int { return &c.MaxRangeDays }),
+}
+
+// Загрузка конфигурации по слоям: значения по умолчанию, JSON-файл, переменные окружения, флаги args.
+// Путь к файлу задается флагом -config или переменной CALENDAR_CONFIG; без него файл не читается.
+// Переменные окружения читаются функцией lookupEnv
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	conf := Default()
+
+	// Флаги разбираются первыми, чтобы узнать путь к файлу, но применяются последними
+	fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	configPath := fs.String(configSetting, "", "path to a JSON configuration file (env "+envName(configSetting)+")")
+	for _, s := range settings {
+		fs.String(flagName(s.name), "", s.usage+" (env "+envName(s.name)+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *configPath == "" {
+		*configPath, _ = lookupEnv(envName(configSetting))
+	}
+
+	if *configPath != "" {
+		if err := applyFile(&conf, *configPath); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(envName(s.name)); ok {
+			if err := s.set(&conf, value); err != nil {
+				return Config{}, fmt.Errorf("env %s: %w", envName(s.name), err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if flagErr == nil && f.Name == flagName(s.name) {
+				if err := s.set(&conf, f.Value.String()); err != nil {
+					flagErr = fmt.Errorf("flag -%s: %w", f.Name, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+
+	if err := conf.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return conf, nil
+}
+
+// Применение настроек из JSON-файла fileName. Неизвестные ключи считаются ошибкой
+func applyFile(conf *Config, fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	values := map[string]any{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("config file %s: decoding JSON: %w", fileName, err)
+	}
+
+	for key, value := range values {
+		s, ok := findSetting(key)
+		if !ok {
+			return fmt.Errorf("config file %s: unknown setting %q", fileName, key)
+		}
+
+		var str string
+		switch v := value.(type) {
+		case string:
+			str = v
+		case json.Number:
+			str = v.String()
+		default:
+			return fmt.Errorf("config file %s: %s: should be a string or a number", fileName, key)
+		}
+
+		if err := s.set(conf, str); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", fileName, key, err)
+		}
+	}
+
+	return nil
+}
+
+// Поиск настройки по ключу
+func findSetting(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// Имя переменной окружения для настройки
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(name)
+}
+
+// Имя флага для настройки
+func flagName(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// Строковая настройка
+func stringSetting(name, usage string, field func(c *Config) *string) setting {
+	return setting{name: name, usage: usage, set: func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}}
+}
+
+// Настройка списка строк через запятую. Пустые элементы пропускаются
+func listSetting(name, usage string, field func(c *Config) *[]string) setting {
+	return setting{name: name, usage: usage, set: func(c *Config, value string) error {
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}}
+}
+
+// Целочисленная настройка
+func intSetting(name, usage string, field func(c *Config) *int) setting {
+	return setting{name: name, usage: usage, set: func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("should be an integer, got %q", value)
+		}
+		*field(c) = n
+		return nil
+	}}
+}
+
+// Настройка длительности в формате time.ParseDuration ("30s", "1m30s"). Число без единиц - секунды
+func durationSetting(name, usage string, field func(c *Config) *time.Duration) setting {
+	return setting{name: name, usage: usage, set: func(c *Config, value string) error {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			*field(c) = time.Duration(seconds) * time.Second
+			return nil
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("should be a duration like 30s or 1m, got %q", value)
+		}
+		*field(c) = d
+		return nil
+	}}
+}
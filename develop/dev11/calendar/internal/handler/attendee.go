@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/api_helper"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Вложенный ресурс участников события REST API v2
+	attendeesResource = "attendees"
+
+	// Методы, допустимые для списка участников и для отдельного участника
+	attendeesV2Allow = "POST"
+	attendeeV2Allow  = "PUT, DELETE"
+)
+
+// Ошибка ответа на приглашение за другого участника
+var errRespondForOther = model.NewError(model.KindForbidden, "forbidden", "only the attendee can respond to the invitation")
+
+// Тело запроса приглашения участников
+type inviteRequest struct {
+	UserIDs []int `json:"user_ids"`
+}
+
+// Тело запроса ответа на приглашение
+type respondRequest struct {
+	Status string `json:"status"`
+}
+
+// Участники события id: POST /api/v2/events/{id}/attendees,
+// PUT и DELETE /api/v2/events/{id}/attendees/{user_id}. sub - путь после ID события
+func (h *eventHandler) attendeesV2(w http.ResponseWriter, r *http.Request, id int, sub string) {
+	resource, userPart, hasUser := strings.Cut(sub, "/")
+	if resource != attendeesResource {
+		notFound(w)
+		return
+	}
+
+	if !hasUser {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, attendeesV2Allow)
+			return
+		}
+		h.inviteV2(w, r, id)
+		return
+	}
+
+	// Получение ID участника из пути
+	attendeeID, err := strconv.Atoi(userPart)
+	if err != nil || attendeeID <= 0 {
+		writeError(w, model.NewValidationError("user_id", "in path should be a positive integer"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.respondV2(w, r, id, attendeeID)
+	case http.MethodDelete:
+		h.removeAttendeeV2(w, r, id, attendeeID)
+	default:
+		methodNotAllowed(w, attendeeV2Allow)
+	}
+}
+
+// Приглашение участников организатором
+func (h *eventHandler) inviteV2(w http.ResponseWriter, r *http.Request, id int) {
+	// Десериализация параметров с валидацией по схеме InviteAttendees
+	var req inviteRequest
+	if err := readValidJSON(w, r, "InviteAttendees", &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	event, err := h.eventService.InviteAttendees(currentUser(r), id, req.UserIDs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeEvent(w, event)
+}
+
+// Ответ участника на приглашение. Ответить можно только за себя
+func (h *eventHandler) respondV2(w http.ResponseWriter, r *http.Request, id, attendeeID int) {
+	// Десериализация параметров с валидацией по схеме RespondToInvitation
+	var req respondRequest
+	if err := readValidJSON(w, r, "RespondToInvitation", &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if attendeeID != currentUser(r) {
+		writeError(w, errRespondForOther)
+		return
+	}
+
+	event, err := h.eventService.RespondToInvitation(attendeeID, id, req.Status)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeEvent(w, event)
+}
+
+// Исключение участника организатором или отказ участника от события
+func (h *eventHandler) removeAttendeeV2(w http.ResponseWriter, r *http.Request, id, attendeeID int) {
+	if err := h.eventService.RemoveAttendee(currentUser(r), id, attendeeID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Ответ с событием event и его версией
+func writeEvent(w http.ResponseWriter, event model.Event) {
+	var payload api_helper.JsonResponse
+	payload.Result = event
+
+	api_helper.WriteJSON(w, http.StatusOK, payload, eventHeaders(event))
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_eventHandler_authentication(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp := request(t, srv, 1, http.MethodPost, eventsV2Path, `{"date":"2023-05-01","description":"a"}`)
+	expectStatus(t, resp, http.StatusCreated)
+	location := resp.Header.Get("Location")
+
+	// Без токена или с неверной подписью - 401 на любом маршруте
+	for _, path := range []string{eventsV2Path, location, "/events_for_day?date=2023-05-01"} {
+		expectStatus(t, request(t, srv, 0, http.MethodGet, path, ""), http.StatusUnauthorized)
+		expectStatus(t, request(t, srv, 0, http.MethodGet, path, "", "Authorization", "Bearer 1.bad"), http.StatusUnauthorized)
+	}
+
+	// Аутентифицированному пользователю чужое событие не видно, а изменить его он не может - 403
+	expectStatus(t, request(t, srv, 2, http.MethodGet, location, ""), http.StatusNotFound)
+	expectStatus(t, request(t, srv, 2, http.MethodPut, location, `{"date":"2023-05-02","description":"b"}`), http.StatusForbidden)
+	expectStatus(t, request(t, srv, 2, http.MethodDelete, location, ""), http.StatusForbidden)
+
+	// Владелец получает событие
+	expectStatus(t, request(t, srv, 1, http.MethodGet, location, ""), http.StatusOK)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/openapi"
+	"dev11/calendar/internal/service"
+	"dev11/calendar/pkg/api_helper"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Путь пакетного изменения событий
+const batchPath = "/events/batch"
+
+// Тело запроса пакетного изменения
+type batchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []batchOperation `json:"operations"`
+}
+
+// Операция пакета в теле запроса. Событие проверяется схемой, соответствующей виду операции
+type batchOperation struct {
+	Op      string          `json:"op"`
+	ID      int             `json:"id"`
+	Version int             `json:"version"`
+	Event   json.RawMessage `json:"event"`
+}
+
+// Результат операции пакета в ответе: код ответа, который получила бы отдельная операция,
+// ID и версия события либо описание ошибки
+type batchResult struct {
+	Status  int                 `json:"status"`
+	ID      int                 `json:"id,omitempty"`
+	Version int                 `json:"version,omitempty"`
+	Error   *api_helper.Problem `json:"error,omitempty"`
+}
+
+// Пакетное изменение событий: POST /events/batch.
+// Атомарный пакет применяется целиком, а при ошибке любой операции не применяется вовсе, и ответом
+// становится ошибка этой операции с путем operations[i]. Пакет без флага atomic применяет
+// все корректные операции и возвращает результат каждой операции по порядку
+func (h *eventHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Десериализация пакета с валидацией по схеме Batch
+	var req batchRequest
+	if err := readValidJSON(w, r, "Batch", &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Валидация операций. Некорректные операции пакета без флага atomic получают результат сразу
+	results := make([]batchResult, len(req.Operations))
+	ops := make([]service.BatchOperationDTO, 0, len(req.Operations))
+	indexes := make([]int, 0, len(req.Operations))
+	for i, op := range req.Operations {
+		dto, err := batchOperationDTO(op)
+		if err != nil {
+			if req.Atomic {
+				api_helper.WriteProblem(w, batchProblem(i, err))
+				return
+			}
+			results[i] = failedResult(err)
+			continue
+		}
+
+		ops = append(ops, dto)
+		indexes = append(indexes, i)
+	}
+
+	// Применение пакета
+	applied, err := h.eventService.Batch(currentUser(r), ops, req.Atomic)
+	var batchErr *service.BatchError
+	if errors.As(err, &batchErr) {
+		api_helper.WriteProblem(w, batchProblem(indexes[batchErr.Index], batchErr.Err))
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Результаты операций по порядку пакета
+	failed := len(req.Operations) - len(ops)
+	for j, result := range applied {
+		i := indexes[j]
+		if result.Err != nil {
+			results[i] = failedResult(result.Err)
+			failed++
+			continue
+		}
+
+		status := http.StatusOK
+		if ops[j].Op == service.BatchInsert {
+			status = http.StatusCreated
+		}
+		results[i] = batchResult{Status: status, ID: result.ID, Version: result.Version}
+	}
+
+	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = struct {
+		Applied    int           `json:"applied"`
+		Failed     int           `json:"failed"`
+		Operations []batchResult `json:"operations"`
+	}{Applied: len(results) - failed, Failed: failed, Operations: results}
+
+	// Оформление ответа
+	api_helper.WriteJSON(w, http.StatusOK, payload)
+}
+
+// DTO операции пакета из операции op тела запроса. Событие вставки проверяется схемой InsertEvent,
+// событие замены - схемой ReplaceEvent, а их поля в ошибках получают путь event.
+// Ожидаемая версия заменяемого события берется из операции, а без нее - из события
+func batchOperationDTO(op batchOperation) (service.BatchOperationDTO, error) {
+	dto := service.BatchOperationDTO{Op: op.Op, ID: op.ID, Version: op.Version}
+
+	if op.Op != service.BatchInsert && op.ID == 0 {
+		return dto, model.NewValidationError("id", "is required for "+op.Op)
+	}
+	if op.Op == service.BatchDelete {
+		return dto, nil
+	}
+
+	if op.Event == nil {
+		return dto, model.NewValidationError("event", "is required for "+op.Op)
+	}
+
+	schema := "InsertEvent"
+	if op.Op == service.BatchUpdate {
+		schema = "ReplaceEvent"
+	}
+	if err := openapi.ValidateJSON(schema, op.Event); err != nil {
+		return dto, prefixFields(err, "event.")
+	}
+
+	// Схема уже проверена, поэтому ошибка означает несоответствие типа Go, например переполнение числа
+	if err := json.Unmarshal(op.Event, &dto.Event); err != nil {
+		return dto, model.NewValidationError("event", fmt.Sprintf("can't be decoded: %v", err))
+	}
+	dto.Event.ID, dto.Event.Occurrence = op.ID, ""
+	if dto.Version == 0 {
+		dto.Version = dto.Event.Version
+	}
+
+	if err := validateUpdateDto(dto.Event); err != nil {
+		return dto, prefixFields(err, "event.")
+	}
+
+	return dto, nil
+}
+
+// Результат операции пакета, завершившейся ошибкой err
+func failedResult(err error) batchResult {
+	problem := api_helper.CompleteProblem(problemOf(err))
+	return batchResult{Status: problem.Status, Error: &problem}
+}
+
+// Описание ошибки err операции пакета с номером index для ответа на атомарный пакет
+func batchProblem(index int, err error) api_helper.Problem {
+	prefix := fmt.Sprintf("operations[%d]", index)
+
+	var typed *model.Error
+	if errors.As(err, &typed) && len(typed.Fields) > 0 {
+		return problemOf(prefixFields(err, prefix+"."))
+	}
+
+	problem := problemOf(err)
+	problem.Detail = prefix + ": " + problem.Detail
+	return problem
+}
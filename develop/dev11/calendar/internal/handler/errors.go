@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/api_helper"
+	"errors"
+	"net/http"
+)
+
+// Коды ответа HTTP по видам ошибок предметной области
+var kindStatuses = map[model.ErrorKind]int{
+	model.KindValidation: http.StatusBadRequest,
+	model.KindNotFound:   http.StatusNotFound,
+	model.KindForbidden:  http.StatusForbidden,
+	model.KindConflict:   http.StatusConflict,
+}
+
+// Ответ на ошибку в формате application/problem+json. Код ответа выбирается по виду
+// типизированной ошибки, превышение размера тела - 413, остальные ошибки - 500
+// без подробностей, чтобы не раскрывать внутреннее устройство сервиса
+func writeError(w http.ResponseWriter, err error) {
+	api_helper.WriteProblem(w, problemOf(err))
+}
+
+// Ответ на ошибку изменения события: конфликт версий при проверке заголовком If-Match - 412,
+// остальные ошибки - как в writeError
+func writeMutationError(w http.ResponseWriter, err error, ifMatch bool) {
+	problem := problemOf(err)
+	if ifMatch && errors.Is(err, model.ErrVersionConflict) {
+		problem.Status = http.StatusPreconditionFailed
+	}
+
+	api_helper.WriteProblem(w, problem)
+}
+
+// Ответ 405 со списком допустимых методов
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	headers := http.Header{}
+	headers.Set("Allow", allow)
+
+	api_helper.WriteProblem(w, api_helper.Problem{
+		Status: http.StatusMethodNotAllowed,
+		Code:   "method_not_allowed",
+		Detail: "method not allowed",
+	}, headers)
+}
+
+// Ответ 404 на адрес, не соответствующий ни одному ресурсу
+func notFound(w http.ResponseWriter) {
+	api_helper.WriteProblem(w, api_helper.Problem{
+		Status: http.StatusNotFound,
+		Code:   "not_found",
+		Detail: "resource not found",
+	})
+}
+
+// Описание ошибки err для ответа
+func problemOf(err error) api_helper.Problem {
+	var typed *model.Error
+	if errors.As(err, &typed) {
+		status, ok := kindStatuses[typed.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+
+		problem := api_helper.Problem{Status: status, Code: typed.Code, Detail: err.Error()}
+		for _, field := range typed.Fields {
+			problem.Fields = append(problem.Fields, api_helper.ProblemField{Field: field.Field, Message: field.Message})
+		}
+		return problem
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return api_helper.Problem{
+			Status: http.StatusRequestEntityTooLarge,
+			Code:   "payload_too_large",
+			Detail: err.Error(),
+		}
+	}
+
+	return api_helper.Problem{
+		Status: http.StatusInternalServerError,
+		Code:   "internal_error",
+		Detail: "internal error",
+	}
+}
+
+// Ошибка err с путем prefix перед именами полей, если это ошибка валидации полей
+func prefixFields(err error, prefix string) error {
+	var typed *model.Error
+	if !errors.As(err, &typed) || len(typed.Fields) == 0 {
+		return err
+	}
+
+	prefixed := &model.Error{Kind: typed.Kind, Code: typed.Code, Message: prefix + typed.Message}
+	for _, field := range typed.Fields {
+		prefixed.Fields = append(prefixed.Fields, model.FieldError{Field: prefix + field.Field, Message: field.Message})
+	}
+
+	return prefixed
+}
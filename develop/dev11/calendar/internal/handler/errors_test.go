@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/api_helper"
+)
+
+func Test_writeMutationError(t *testing.T) {
+	conflict := fmt.Errorf("%w: expected version 1, current version 2", model.ErrVersionConflict)
+
+	tests := []struct {
+		name    string
+		err     error
+		ifMatch bool
+		status  int
+		code    string
+	}{
+		{"validation", model.NewValidationError("date", "is required"), false, http.StatusBadRequest, model.CodeValidation},
+		{"not found", model.ErrEventNotFound, false, http.StatusNotFound, "event_not_found"},
+		{"wrapped not found", fmt.Errorf("%w: event 5", model.ErrOccurrenceNotFound), false, http.StatusNotFound, "occurrence_not_found"},
+		{"forbidden", model.ErrForbidden, false, http.StatusForbidden, "forbidden"},
+		{"version conflict in body", conflict, false, http.StatusConflict, "version_conflict"},
+		{"version conflict with If-Match", conflict, true, http.StatusPreconditionFailed, "version_conflict"},
+		{"other conflict with If-Match", model.ErrSeriesDeleted, true, http.StatusConflict, "series_deleted"},
+		{"body too large", &http.MaxBytesError{Limit: 1}, false, http.StatusRequestEntityTooLarge, "payload_too_large"},
+		{"untyped", errors.New("disk on fire"), false, http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeMutationError(rec, tt.err, tt.ifMatch)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", contentType)
+			}
+
+			var problem api_helper.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decoding problem: %v", err)
+			}
+			if problem.Status != tt.status || problem.Code != tt.code || problem.Title != http.StatusText(tt.status) {
+				t.Errorf("problem = %+v, want status %d and code %q", problem, tt.status, tt.code)
+			}
+
+			// Текст прежнего формата {"error": ...} совпадает с описанием
+			if problem.Error == "" || problem.Error != problem.Detail {
+				t.Errorf("error = %q, want detail %q", problem.Error, problem.Detail)
+			}
+
+			// Подробности внутренних ошибок не раскрываются
+			if tt.status == http.StatusInternalServerError && problem.Detail != "internal error" {
+				t.Errorf("internal error detail = %q", problem.Detail)
+			}
+		})
+	}
+}
+
+func Test_problemOf_fields(t *testing.T) {
+	err := prefixFields(model.NewValidationError("date", "should be in format 2006-01-02"), "operations[2].")
+
+	problem := problemOf(err)
+	want := []api_helper.ProblemField{{Field: "operations[2].date", Message: "should be in format 2006-01-02"}}
+	if problem.Status != http.StatusBadRequest || len(problem.Fields) != 1 || problem.Fields[0] != want[0] {
+		t.Errorf("problemOf() = %+v, want fields %+v", problem, want)
+	}
+}
@@ -1,137 +1,242 @@
 package handler
 
 import (
+	"dev11/calendar/internal/metrics"
 	"dev11/calendar/internal/middleware"
 	"dev11/calendar/internal/model"
 	"dev11/calendar/internal/service"
 	"dev11/calendar/pkg/api_helper"
-	"encoding/json"
-	"errors"
+	"dev11/calendar/pkg/logger"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+const (
+	// Наибольший размер страницы списка событий
+	maxListLimit = 1000
+	// Код успешного ответа устаревших маршрутов изменения событий. Сохраняется для существующих
+	// клиентов; REST API v2 отвечает 201 на создание и 200 на изменение
+	legacyWriteStatus = http.StatusAccepted
+)
+
 // Хэндлер событий
 type eventHandler struct {
 	eventService service.IEventService
+	logger       logger.ILogger
+	metrics      *metrics.HTTPMetrics
+	// Промежуточный слой аутентификации
+	auth func(http.Handler) http.Handler
+	// Промежуточный слой идемпотентной обработки запросов создания и изменения
+	idempotent func(http.Handler) http.Handler
 }
 
 // Конструктор хэндлера событий
-func NewEventHandler(eventService service.IEventService) IEventHandler {
+func NewEventHandler(eventService service.IEventService, logger logger.ILogger, metrics *metrics.HTTPMetrics,
+	auth, idempotent func(http.Handler) http.Handler) IEventHandler {
 	return &eventHandler{
 		eventService: eventService,
+		logger:       logger,
+		metrics:      metrics,
+		auth:         auth,
+		idempotent:   idempotent,
 	}
 }
 
-// Регистрация конкретных обработчиков в роутере router
+// Регистрация конкретных обработчиков в роутере router. Все обработчики требуют аутентификации,
+// запросы создания и изменения событий принимают ключ идемпотентности
 func (h *eventHandler) Register(router *http.ServeMux) {
-	router.Handle("/create_event", middleware.Log(http.HandlerFunc(h.Insert)))
-	router.Handle("/update_event", middleware.Log(http.HandlerFunc(h.Update)))
-	router.Handle("/delete_event", middleware.Log(http.HandlerFunc(h.Remove)))
-	router.Handle("/events_for_day", middleware.Log(http.HandlerFunc(h.GetForDay)))
-	router.Handle("/events_for_week", middleware.Log(http.HandlerFunc(h.GetForWeek)))
-	router.Handle("/events_for_month", middleware.Log(http.HandlerFunc(h.GetForMonth)))
+	chain := func(pattern string, handler http.Handler) http.Handler {
+		return middleware.Log(h.logger, pattern)(middleware.Metrics(h.metrics, pattern)(h.auth(handler)))
+	}
+	handle := func(pattern string, handler http.HandlerFunc) {
+		router.Handle(pattern, chain(pattern, handler))
+	}
+	handleIdempotent := func(pattern string, handler http.HandlerFunc) {
+		router.Handle(pattern, chain(pattern, h.idempotent(handler)))
+	}
+
+	handleIdempotent("/create_event", h.Insert)
+	handleIdempotent("/update_event", h.Update)
+	handle("/delete_event", h.Remove)
+	handle("/events_for_day", h.GetForDay)
+	handle("/events_for_week", h.GetForWeek)
+	handle("/events_for_month", h.GetForMonth)
+	handle("/events_for_range", h.GetForRange)
+	handle("/event", h.GetByID)
+	handle("/events.ics", h.ExportICS)
+	handle("/import_ics", h.ImportICS)
+	handleIdempotent(batchPath, h.Batch)
+
+	// REST API v2
+	handleIdempotent(eventsV2Path, h.EventsV2)
+	handleIdempotent(eventsV2Path+"/", h.EventV2)
+	handle(trashV2Path, h.TrashV2)
+	handle(trashV2Path+"/", h.TrashItemV2)
+	handle(freeBusyV2Path, h.FreeBusyV2)
+	handle(freeSlotV2Path, h.FreeSlotV2)
 }
 
 // Добавление события
 func (h *eventHandler) Insert(w http.ResponseWriter, r *http.Request) {
 	// Обработка несоответствия метода запроса
 	if r.Method != http.MethodPost {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	// Десериализация параметров
+	// Десериализация параметров с валидацией по схеме InsertEvent
 	var dto service.InsertEventDTO
-	err := json.NewDecoder(r.Body).Decode(&dto)
+	err := readValidJSON(w, r, "InsertEvent", &dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Валидация параметров
 	err = validateInsertDto(dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	// Режим отказа в изменении при занятости участников
+	dto.RejectOnConflict, err = parseRejectOnConflict(r)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	// Вставка события
-	id := h.eventService.Insert(dto)
+	id, conflicts, err := h.eventService.Insert(currentUser(r), dto)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
 	// Возвращаемое значение
 	var payload api_helper.JsonResponse
 	payload.Result = struct {
 		Id int `json:"id"`
 	}{Id: id}
+	payload.Warnings = conflictWarnings(conflicts)
 
-	// Оформление ответа
-	api_helper.WriteJSON(w, http.StatusAccepted, payload)
+	// Оформление ответа с адресом созданного ресурса
+	headers := http.Header{}
+	headers.Set("Location", eventsV2Path+"/"+strconv.Itoa(id))
+	api_helper.WriteJSON(w, legacyWriteStatus, payload, headers)
 }
 
 func (h *eventHandler) Update(w http.ResponseWriter, r *http.Request) {
 	// Обработка несоответствия метода запроса
 	if r.Method != http.MethodPost {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	// Десериализация параметров
+	// Десериализация параметров с валидацией по схеме UpdateEvent
 	var dto service.UpdateEventDTO
-	err := json.NewDecoder(r.Body).Decode(&dto)
+	err := readValidJSON(w, r, "UpdateEvent", &dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Валидация параметров
 	err = validateUpdateDto(dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	// Режим отказа в изменении при занятости участников
+	dto.RejectOnConflict, err = parseRejectOnConflict(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Ожидаемая версия события
+	version, ifMatch, err := expectedVersion(r, dto.Version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Обновление отдельного повторения серии
+	if dto.Occurrence != "" {
+		id, conflicts, err := h.eventService.UpdateOccurrence(currentUser(r), dto.ID, dto.Occurrence, version, dto)
+		if err != nil {
+			writeMutationError(w, err, ifMatch)
+			return
+		}
+
+		// Возвращаемое значение
+		var payload api_helper.JsonResponse
+		payload.Result = struct {
+			Id int `json:"id"`
+		}{Id: id}
+		payload.Warnings = conflictWarnings(conflicts)
+
+		// Оформление ответа
+		api_helper.WriteJSON(w, legacyWriteStatus, payload)
 		return
 	}
 
 	// Обновление события
-	err = h.eventService.Update(dto.UserId, dto)
+	conflicts, err := h.eventService.Update(currentUser(r), dto.ID, version, dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		writeMutationError(w, err, ifMatch)
 		return
 	}
 
 	// Возвращаемое значение
 	var payload api_helper.JsonResponse
 	payload.Result = "ok"
+	payload.Warnings = conflictWarnings(conflicts)
 
 	// Оформление ответа
-	api_helper.WriteJSON(w, http.StatusAccepted, payload)
+	api_helper.WriteJSON(w, legacyWriteStatus, payload)
 }
 
 func (h *eventHandler) Remove(w http.ResponseWriter, r *http.Request) {
 	// Обработка несоответствия метода запроса
 	if r.Method != http.MethodPost {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	// Десериализация параметров
+	// Десериализация параметров с валидацией по схеме RemoveEvent
 	var dto service.RemoveEventDTO
-	err := json.NewDecoder(r.Body).Decode(&dto)
+	err := readValidJSON(w, r, "RemoveEvent", &dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Валидация параметров
 	err = validateRemoveDto(dto)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	// Ожидаемая версия события
+	version, ifMatch, err := expectedVersion(r, dto.Version)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	// Удаление события
-	err = h.eventService.Remove(dto.ID)
+	// Удаление события или отдельного повторения серии
+	if dto.Occurrence != "" {
+		err = h.eventService.RemoveOccurrence(currentUser(r), dto.ID, dto.Occurrence, version)
+	} else {
+		err = h.eventService.Remove(currentUser(r), dto.ID, version)
+	}
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		writeMutationError(w, err, ifMatch)
 		return
 	}
 
@@ -140,172 +245,419 @@ func (h *eventHandler) Remove(w http.ResponseWriter, r *http.Request) {
 	payload.Result = "ok"
 
 	// Оформление ответа
-	api_helper.WriteJSON(w, http.StatusAccepted, payload)
+	api_helper.WriteJSON(w, legacyWriteStatus, payload)
 }
 
 func (h *eventHandler) GetForDay(w http.ResponseWriter, r *http.Request) {
 	// Обработка несоответствия метода запроса
 	if r.Method != http.MethodGet {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	// Получение параметра date
 	date := r.URL.Query().Get("date")
 	if date == "" {
-		api_helper.ErrorJSON(w, errors.New("date parameter is not defined"), http.StatusBadRequest)
+		writeError(w, model.NewValidationError("date", "is required"))
 		return
 	}
 
 	// Валидация даты
-	err := validateDate(date)
+	err := validateDate("date", date)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	// Получение событий за дату date
-	events, err := h.eventService.GetForDay(date)
+	// Получение и валидация часового пояса, в котором отсчитывается дата
+	tz := r.URL.Query().Get("tz")
+	if err := validateTimeZone(tz); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение параметров страницы и фильтров
+	opts, err := parseListParams(r)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		writeError(w, err)
 		return
 	}
 
-	// Возвращаемое значение
-	var payload api_helper.JsonResponse
-	payload.Result = struct {
-		Events []model.Event `json:"events"`
-	}{Events: events}
+	// Получение событий за дату date
+	page, err := h.eventService.GetForDay(currentUser(r), date, tz, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
 	// Оформление ответа
-	api_helper.WriteJSON(w, http.StatusAccepted, payload)
+	writeEventPage(w, http.StatusOK, page, opts)
 }
 
 func (h *eventHandler) GetForWeek(w http.ResponseWriter, r *http.Request) {
 	// Обработка несоответствия метода запроса
 	if r.Method != http.MethodGet {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	// Получение параметра date
 	date := r.URL.Query().Get("date")
 	if date == "" {
-		api_helper.ErrorJSON(w, errors.New("date parameter is not defined"), http.StatusBadRequest)
+		writeError(w, model.NewValidationError("date", "is required"))
 		return
 	}
 
 	// Валидация даты
-	err := validateDate(date)
+	err := validateDate("date", date)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
-	// Получение событий за неделю, в которой имеется дата date
-	events, err := h.eventService.GetForWeek(date)
+	// Получение и валидация часового пояса, в котором отсчитывается дата
+	tz := r.URL.Query().Get("tz")
+	if err := validateTimeZone(tz); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение параметров страницы и фильтров
+	opts, err := parseListParams(r)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		writeError(w, err)
 		return
 	}
 
-	// Возвращаемое значение
-	var payload api_helper.JsonResponse
-	payload.Result = struct {
-		Events []model.Event `json:"events"`
-	}{Events: events}
+	// Получение событий за неделю, в которой имеется дата date
+	page, err := h.eventService.GetForWeek(currentUser(r), date, tz, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
 	// Оформление ответа
-	api_helper.WriteJSON(w, http.StatusAccepted, payload)
+	writeEventPage(w, http.StatusOK, page, opts)
 }
 
 func (h *eventHandler) GetForMonth(w http.ResponseWriter, r *http.Request) {
 	// Обработка несоответствия метода запроса
 	if r.Method != http.MethodGet {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	// Получение параметра date
 	date := r.URL.Query().Get("date")
 	if date == "" {
-		api_helper.ErrorJSON(w, errors.New("date parameter is not defined"), http.StatusBadRequest)
+		writeError(w, model.NewValidationError("date", "is required"))
 		return
 	}
 
 	// Валидация даты
-	err := validateDate(date)
+	err := validateDate("date", date)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	// Получение и валидация часового пояса, в котором отсчитывается дата
+	tz := r.URL.Query().Get("tz")
+	if err := validateTimeZone(tz); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение параметров страницы и фильтров
+	opts, err := parseListParams(r)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	// Получение событий за месяц, в котором имеется дата date
-	events, err := h.eventService.GetForMonth(date)
+	page, err := h.eventService.GetForMonth(currentUser(r), date, tz, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Оформление ответа
+	writeEventPage(w, http.StatusOK, page, opts)
+}
+
+func (h *eventHandler) GetForRange(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Получение и валидация границ периода
+	from, to, bounds, tz, err := parseRangeParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение параметров страницы и фильтров
+	opts, err := parseListParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение событий за период
+	page, err := h.eventService.GetRange(currentUser(r), from, to, bounds, tz, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Оформление ответа
+	writeEventPage(w, http.StatusOK, page, opts)
+}
+
+func (h *eventHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Получение параметра id
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		writeError(w, model.NewValidationError("id", "should be a positive integer"))
+		return
+	}
+
+	// Получение события
+	event, err := h.eventService.GetByID(currentUser(r), id)
 	if err != nil {
-		api_helper.ErrorJSON(w, err, http.StatusServiceUnavailable)
+		writeError(w, err)
 		return
 	}
 
 	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = event
+
+	// Оформление ответа с версией события
+	api_helper.WriteJSON(w, http.StatusOK, payload, eventHeaders(event))
+}
+
+// ID пользователя, аутентифицированного промежуточным слоем
+func currentUser(r *http.Request) int {
+	userID, _ := middleware.UserID(r.Context())
+	return userID
+}
+
+// Получение параметров периода from, to, bounds и tz. По умолчанию обе границы включаются,
+// а даты отсчитываются в UTC
+func parseRangeParams(r *http.Request) (from, to, bounds, tz string, err error) {
+	query := r.URL.Query()
+
+	from, to = query.Get("from"), query.Get("to")
+	if from == "" {
+		return "", "", "", "", model.NewValidationError("from", "is required")
+	}
+	if to == "" {
+		return "", "", "", "", model.NewValidationError("to", "is required")
+	}
+
+	if err := validateDate("from", from); err != nil {
+		return "", "", "", "", err
+	}
+	if err := validateDate("to", to); err != nil {
+		return "", "", "", "", err
+	}
+
+	bounds = query.Get("bounds")
+	if bounds == "" {
+		bounds = model.BoundsInclusive
+	}
+
+	if _, err := model.NewRange(time.Time{}, time.Time{}, bounds); err != nil {
+		return "", "", "", "", err
+	}
+
+	tz = query.Get("tz")
+	if err := validateTimeZone(tz); err != nil {
+		return "", "", "", "", err
+	}
+
+	return from, to, bounds, tz, nil
+}
+
+// Получение режима reject_on_conflict: отказ в добавлении или изменении события,
+// время которого уже занято у организатора или участников. По умолчанию выключен
+func parseRejectOnConflict(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("reject_on_conflict")
+	if value == "" {
+		return false, nil
+	}
+
+	reject, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, model.NewValidationError("reject_on_conflict", "should be true or false")
+	}
+
+	return reject, nil
+}
+
+// Предупреждения о пересечениях с занятостью участников, записанных без режима reject_on_conflict
+func conflictWarnings(conflicts []service.Conflict) []api_helper.Warning {
+	var warnings []api_helper.Warning
+	for _, conflict := range conflicts {
+		warnings = append(warnings, api_helper.Warning{Code: model.ErrScheduleConflict.Code, Detail: conflict.String()})
+	}
+
+	return warnings
+}
+
+// Получение параметров страницы limit и cursor и фильтров user_id и description
+func parseListParams(r *http.Request) (service.ListOptions, error) {
+	query := r.URL.Query()
+
+	opts := service.ListOptions{
+		Cursor:      query.Get("cursor"),
+		Description: query.Get("description"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if opts.Limit, err = strconv.Atoi(limit); err != nil || opts.Limit <= 0 || opts.Limit > maxListLimit {
+			return service.ListOptions{}, model.NewValidationError("limit", fmt.Sprintf("should be an integer from 1 to %d", maxListLimit))
+		}
+	}
+
+	if userID := query.Get("user_id"); userID != "" {
+		var err error
+		if opts.UserID, err = strconv.Atoi(userID); err != nil || opts.UserID <= 0 {
+			return service.ListOptions{}, model.NewValidationError("user_id", "should be a positive integer")
+		}
+	}
+
+	return opts, nil
+}
+
+// Ответ со страницей событий page, выбранной по параметрам opts
+func writeEventPage(w http.ResponseWriter, status int, page service.EventPage, opts service.ListOptions) {
 	var payload api_helper.JsonResponse
 	payload.Result = struct {
 		Events []model.Event `json:"events"`
-	}{Events: events}
+	}{Events: page.Events}
+	payload.Paging = &api_helper.Paging{
+		Count:      len(page.Events),
+		Limit:      opts.Limit,
+		NextCursor: page.NextCursor,
+	}
 
-	// Оформление ответа
-	api_helper.WriteJSON(w, http.StatusAccepted, payload)
+	api_helper.WriteJSON(w, status, payload)
 }
 
-// Валидация параметров для вставки события
+// Валидация параметров для вставки события, не выражаемая схемой InsertEvent
 func validateInsertDto(dto service.InsertEventDTO) error {
-	if dto.UserId < 0 {
-		return errors.New("user id parameter should be positive")
+	if err := validateEventOrder(dto.Start, dto.End); err != nil {
+		return err
 	}
 
-	if len(dto.Description) == 0 {
-		return errors.New("description parameter should not be empty")
+	if dto.Recurrence != nil {
+		return dto.Recurrence.Validate()
 	}
 
-	if err := validateDate(dto.Date); err != nil {
+	return nil
+}
+
+// Валидация параметров для обновления события, не выражаемая схемами UpdateEvent и ReplaceEvent.
+// Дата повторения в REST API v2 передается параметром адреса и проверяется здесь
+func validateUpdateDto(dto service.UpdateEventDTO) error {
+	if err := validateEventOrder(dto.Start, dto.End); err != nil {
 		return err
 	}
 
+	if dto.Recurrence != nil {
+		if dto.Occurrence != "" {
+			return model.NewValidationError("recurrence", "is not allowed for a single occurrence")
+		}
+		if err := dto.Recurrence.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if dto.Occurrence != "" {
+		return validateOccurrence(dto.Occurrence)
+	}
+
 	return nil
 }
 
-// Валидация параметров для обновления события
-func validateUpdateDto(dto service.UpdateEventDTO) error {
-	if dto.ID < 0 {
-		return errors.New("id parameter should be positive")
+// Валидация даты повторения при удалении. Остальные поля проверяются схемой RemoveEvent
+// или берутся из адреса REST API v2
+func validateRemoveDto(dto service.RemoveEventDTO) error {
+	if dto.Occurrence != "" {
+		return validateOccurrence(dto.Occurrence)
+	}
+
+	return nil
+}
+
+// Валидация даты повторения серии
+func validateOccurrence(date string) error {
+	if _, err := time.Parse(model.DateLayout, date); err != nil {
+		return model.NewValidationError("occurrence", "should be in format 2006-01-02")
 	}
-	if dto.UserId < 0 {
-		return errors.New("user id parameter should be positive")
+
+	return nil
+}
+
+// Проверка, что окончание события со временем не раньше начала. Форматы проверены схемой
+func validateEventOrder(start, end string) error {
+	if start == "" || end == "" {
+		return nil
 	}
 
-	if len(dto.Description) == 0 {
-		return errors.New("description parameter should not be empty")
+	startTime, err := parseTime("start", start)
+	if err != nil {
+		return err
 	}
 
-	if err := validateDate(dto.Date); err != nil {
+	endTime, err := parseTime("end", end)
+	if err != nil {
 		return err
 	}
 
+	if endTime.Before(startTime) {
+		return model.NewValidationError("end", "should not be before start")
+	}
+
 	return nil
 }
 
-// Валидация параметров для удаления события
-func validateRemoveDto(dto service.RemoveEventDTO) error {
-	if dto.ID < 0 {
-		return errors.New("id parameter should be positive")
+// Парсинг момента времени из параметра name
+func parseTime(name, value string) (time.Time, error) {
+	t, err := time.Parse(model.TimeLayout, value)
+	if err != nil {
+		return time.Time{}, model.NewValidationError(name, "should be in RFC 3339 format")
 	}
+
+	return t, nil
+}
+
+// Валидация часового пояса. Пустой пояс означает UTC
+func validateTimeZone(tz string) error {
+	if _, err := time.LoadLocation(tz); err != nil {
+		return model.NewValidationError("tz", "should be an IANA timezone name")
+	}
+
 	return nil
 }
 
-// Валидация даты
-func validateDate(date string) error {
+// Валидация даты из параметра name
+func validateDate(name, date string) error {
 	if _, err := time.Parse(model.DateLayout, date); err != nil {
-		return errors.New("date parameter should be in format 2006-01-02")
+		return model.NewValidationError(name, "should be in format 2006-01-02")
 	}
 
 	return nil
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_eventHandler_legacyContract(t *testing.T) {
+	srv := newTestServer(t)
+
+	// Устаревшие маршруты изменения отвечают 202, как и прежде
+	resp := request(t, srv, 1, http.MethodPost, "/create_event", `{"date":"2023-05-01","description":"a"}`)
+	expectStatus(t, resp, http.StatusAccepted)
+
+	var created struct {
+		Result struct {
+			ID int `json:"id"`
+		} `json:"result"`
+	}
+	resp.decode(t, &created)
+	if created.Result.ID == 0 || resp.Header.Get("Location") != eventsV2Path+"/1" {
+		t.Errorf("create_event result = %s, Location = %q", resp.body, resp.Header.Get("Location"))
+	}
+
+	expectStatus(t, request(t, srv, 1, http.MethodPost, "/update_event", `{"id":1,"date":"2023-05-02","description":"b"}`), http.StatusAccepted)
+
+	// Ошибки сохраняют поле error прежнего формата
+	resp = request(t, srv, 1, http.MethodPost, "/update_event", `{"id":100,"date":"2023-05-02","description":"b"}`)
+	expectStatus(t, resp, http.StatusNotFound)
+
+	var failed struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	resp.decode(t, &failed)
+	if failed.Error != "event not found" || failed.Code != "event_not_found" {
+		t.Errorf("update_event error body = %s", resp.body)
+	}
+
+	expectStatus(t, request(t, srv, 1, http.MethodPost, "/delete_event", `{"id":1}`), http.StatusAccepted)
+
+	// Чтения отвечают 200
+	expectStatus(t, request(t, srv, 1, http.MethodGet, "/events_for_day?date=2023-05-02", ""), http.StatusOK)
+}
+
+func Test_eventHandler_versionPreconditions(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp := request(t, srv, 1, http.MethodPost, eventsV2Path, `{"date":"2023-05-01","description":"a"}`)
+	expectStatus(t, resp, http.StatusCreated)
+	location := resp.Header.Get("Location")
+
+	update := `{"date":"2023-05-02","description":"b"}`
+	expectStatus(t, request(t, srv, 1, http.MethodPut, location, update), http.StatusOK)
+
+	// Устаревшая версия в If-Match - 412, в теле запроса - 409
+	expectStatus(t, request(t, srv, 1, http.MethodPut, location, update, "If-Match", `"1"`), http.StatusPreconditionFailed)
+	expectStatus(t, request(t, srv, 1, http.MethodPost, "/update_event", `{"id":1,"version":1,"date":"2023-05-02","description":"b"}`), http.StatusConflict)
+	expectStatus(t, request(t, srv, 1, http.MethodPost, "/update_event", `{"id":1,"date":"2023-05-02","description":"b"}`, "If-Match", `"1"`), http.StatusPreconditionFailed)
+	expectStatus(t, request(t, srv, 1, http.MethodDelete, location, "", "If-Match", `"1"`), http.StatusPreconditionFailed)
+
+	// Некорректный тег - ошибка валидации
+	expectStatus(t, request(t, srv, 1, http.MethodPut, location, update, "If-Match", `W/"2"`), http.StatusBadRequest)
+
+	// Текущая версия из ETag принимается
+	resp = request(t, srv, 1, http.MethodGet, location, "")
+	expectStatus(t, resp, http.StatusOK)
+	expectStatus(t, request(t, srv, 1, http.MethodDelete, location, "", "If-Match", resp.Header.Get("ETag")), http.StatusNoContent)
+}
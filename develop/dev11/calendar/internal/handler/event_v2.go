@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/service"
+	"dev11/calendar/pkg/api_helper"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Путь коллекции событий REST API v2
+	eventsV2Path = "/api/v2/events"
+
+	// Методы, допустимые для коллекции и для отдельного события
+	eventsV2Allow = "GET, POST"
+	eventV2Allow  = "GET, PUT, PATCH, DELETE"
+)
+
+// Коллекция событий: POST /api/v2/events, GET /api/v2/events?from=&to=
+func (h *eventHandler) EventsV2(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.createV2(w, r)
+	case http.MethodGet:
+		h.listV2(w, r)
+	default:
+		methodNotAllowed(w, eventsV2Allow)
+	}
+}
+
+// Отдельное событие: GET, PUT, PATCH, DELETE /api/v2/events/{id}
+// и его участники: /api/v2/events/{id}/attendees[/{user_id}]
+func (h *eventHandler) EventV2(w http.ResponseWriter, r *http.Request) {
+	// Получение ID и вложенного ресурса из пути
+	idPart, sub, hasSub := strings.Cut(strings.TrimPrefix(r.URL.Path, eventsV2Path+"/"), "/")
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 {
+		writeError(w, model.NewValidationError("id", "in path should be a positive integer"))
+		return
+	}
+
+	if hasSub {
+		h.attendeesV2(w, r, id, sub)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getV2(w, r, id)
+	case http.MethodPut:
+		h.replaceV2(w, r, id)
+	case http.MethodPatch:
+		h.patchV2(w, r, id)
+	case http.MethodDelete:
+		h.removeV2(w, r, id)
+	default:
+		methodNotAllowed(w, eventV2Allow)
+	}
+}
+
+// Создание события
+func (h *eventHandler) createV2(w http.ResponseWriter, r *http.Request) {
+	// Десериализация параметров с валидацией по схеме InsertEvent
+	var dto service.InsertEventDTO
+	if err := readValidJSON(w, r, "InsertEvent", &dto); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Валидация параметров
+	if err := validateInsertDto(dto); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Режим отказа в изменении при занятости участников
+	var err error
+	if dto.RejectOnConflict, err = parseRejectOnConflict(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Вставка события
+	id, conflicts, err := h.eventService.Insert(currentUser(r), dto)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = struct {
+		Id int `json:"id"`
+	}{Id: id}
+	payload.Warnings = conflictWarnings(conflicts)
+
+	// Оформление ответа с адресом созданного ресурса
+	headers := http.Header{}
+	headers.Set("Location", eventsV2Path+"/"+strconv.Itoa(id))
+	api_helper.WriteJSON(w, http.StatusCreated, payload, headers)
+}
+
+// Получение событий за период
+func (h *eventHandler) listV2(w http.ResponseWriter, r *http.Request) {
+	// Получение и валидация границ периода
+	from, to, bounds, tz, err := parseRangeParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение параметров страницы и фильтров
+	opts, err := parseListParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение событий за период
+	page, err := h.eventService.GetRange(currentUser(r), from, to, bounds, tz, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Оформление ответа
+	writeEventPage(w, http.StatusOK, page, opts)
+}
+
+// Получение события по ID
+func (h *eventHandler) getV2(w http.ResponseWriter, r *http.Request, id int) {
+	h.writeEventV2(w, r, id, nil)
+}
+
+// Ответ с текущим состоянием события id и предупреждениями warnings о его изменении
+func (h *eventHandler) writeEventV2(w http.ResponseWriter, r *http.Request, id int, warnings []api_helper.Warning) {
+	event, err := h.eventService.GetByID(currentUser(r), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = event
+	payload.Warnings = warnings
+
+	// Оформление ответа с версией события
+	api_helper.WriteJSON(w, http.StatusOK, payload, eventHeaders(event))
+}
+
+// Полная замена события
+func (h *eventHandler) replaceV2(w http.ResponseWriter, r *http.Request, id int) {
+	// Десериализация параметров с валидацией по схеме ReplaceEvent. ID и дата повторения берутся из адреса
+	var dto service.UpdateEventDTO
+	if err := readValidJSON(w, r, "ReplaceEvent", &dto); err != nil {
+		writeError(w, err)
+		return
+	}
+	dto.ID = id
+	dto.Occurrence = r.URL.Query().Get("occurrence")
+
+	// Валидация параметров
+	if err := validateUpdateDto(dto); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Режим отказа в изменении при занятости участников
+	var err error
+	if dto.RejectOnConflict, err = parseRejectOnConflict(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Ожидаемая версия события
+	version, ifMatch, err := expectedVersion(r, dto.Version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Обновление отдельного повторения серии возвращает событие этого повторения
+	if dto.Occurrence != "" {
+		overrideID, conflicts, err := h.eventService.UpdateOccurrence(currentUser(r), id, dto.Occurrence, version, dto)
+		if err != nil {
+			writeMutationError(w, err, ifMatch)
+			return
+		}
+
+		h.writeEventV2(w, r, overrideID, conflictWarnings(conflicts))
+		return
+	}
+
+	// Обновление события
+	conflicts, err := h.eventService.Update(currentUser(r), id, version, dto)
+	if err != nil {
+		writeMutationError(w, err, ifMatch)
+		return
+	}
+
+	h.writeEventV2(w, r, id, conflictWarnings(conflicts))
+}
+
+// Частичное обновление события
+func (h *eventHandler) patchV2(w http.ResponseWriter, r *http.Request, id int) {
+	// Десериализация параметров с валидацией по схеме PatchEvent
+	var dto service.PatchEventDTO
+	if err := readValidJSON(w, r, "PatchEvent", &dto); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Валидация заданных параметров
+	if err := validatePatchDto(dto); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Режим отказа в изменении при занятости участников
+	var err error
+	if dto.RejectOnConflict, err = parseRejectOnConflict(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Ожидаемая версия события
+	version, ifMatch, err := expectedVersion(r, dto.Version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Обновление события
+	conflicts, err := h.eventService.Patch(currentUser(r), id, version, dto)
+	if err != nil {
+		writeMutationError(w, err, ifMatch)
+		return
+	}
+
+	h.writeEventV2(w, r, id, conflictWarnings(conflicts))
+}
+
+// Удаление события или отдельного повторения серии (?occurrence=).
+// Ожидаемая версия задается заголовком If-Match
+func (h *eventHandler) removeV2(w http.ResponseWriter, r *http.Request, id int) {
+	dto := service.RemoveEventDTO{ID: id, Occurrence: r.URL.Query().Get("occurrence")}
+	if err := validateRemoveDto(dto); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	version, ifMatch, err := expectedVersion(r, 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if dto.Occurrence != "" {
+		err = h.eventService.RemoveOccurrence(currentUser(r), id, dto.Occurrence, version)
+	} else {
+		err = h.eventService.Remove(currentUser(r), id, version)
+	}
+	if err != nil {
+		writeMutationError(w, err, ifMatch)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Валидация параметров для частичного обновления события, не выражаемая схемой PatchEvent.
+// Согласованность итогового интервала проверяется сервисом
+func validatePatchDto(dto service.PatchEventDTO) error {
+	if dto.Recurrence != nil {
+		return dto.Recurrence.Validate()
+	}
+
+	return nil
+}
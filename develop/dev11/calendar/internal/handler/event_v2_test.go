@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"dev11/calendar/internal/model"
+)
+
+// Событие из ответа REST API v2
+type eventResponse struct {
+	Result model.Event `json:"result"`
+}
+
+// Описания событий страницы из ответа REST API v2
+func pageDescriptions(t *testing.T, resp testResponse) []string {
+	t.Helper()
+
+	var page struct {
+		Result struct {
+			Events []model.Event `json:"events"`
+		} `json:"result"`
+	}
+	resp.decode(t, &page)
+
+	descriptions := []string{}
+	for _, event := range page.Result.Events {
+		descriptions = append(descriptions, event.Description)
+	}
+	return descriptions
+}
+
+func Test_eventHandler_v2Lifecycle(t *testing.T) {
+	srv := newTestServer(t)
+
+	// Создание - 201 с адресом нового события
+	resp := request(t, srv, 1, http.MethodPost, eventsV2Path, `{"date":"2023-05-01","description":"a"}`)
+	expectStatus(t, resp, http.StatusCreated)
+	location := resp.Header.Get("Location")
+	if location != eventsV2Path+"/1" {
+		t.Fatalf("Location = %q, want %s/1", location, eventsV2Path)
+	}
+
+	// Получение по адресу из Location с версией в ETag
+	resp = request(t, srv, 1, http.MethodGet, location, "")
+	expectStatus(t, resp, http.StatusOK)
+	var got eventResponse
+	resp.decode(t, &got)
+	if got.Result.Description != "a" || resp.Header.Get("ETag") != `"1"` {
+		t.Errorf("GET %s = %s, ETag %q", location, resp.body, resp.Header.Get("ETag"))
+	}
+
+	// Полная замена и частичное изменение
+	expectStatus(t, request(t, srv, 1, http.MethodPut, location, `{"date":"2023-05-02","description":"b","reminders":[10]}`), http.StatusOK)
+	expectStatus(t, request(t, srv, 1, http.MethodPatch, location, `{"description":"c"}`), http.StatusOK)
+
+	resp = request(t, srv, 1, http.MethodGet, location, "")
+	resp.decode(t, &got)
+	if got.Result.Date != "2023-05-02" || got.Result.Description != "c" || !reflect.DeepEqual(got.Result.Reminders, []int{10}) {
+		t.Errorf("event after PUT and PATCH = %+v", got.Result)
+	}
+	if resp.Header.Get("ETag") != `"3"` {
+		t.Errorf("ETag after two changes = %q, want \"3\"", resp.Header.Get("ETag"))
+	}
+
+	// Выборка за период
+	expectStatus(t, request(t, srv, 1, http.MethodPost, eventsV2Path, `{"date":"2023-05-05","description":"d"}`), http.StatusCreated)
+	resp = request(t, srv, 1, http.MethodGet, eventsV2Path+"?from=2023-05-01&to=2023-05-03", "")
+	expectStatus(t, resp, http.StatusOK)
+	if descriptions := pageDescriptions(t, resp); !reflect.DeepEqual(descriptions, []string{"c"}) {
+		t.Errorf("events for range = %v, want [c]", descriptions)
+	}
+
+	// Удаление - 204, после него событие не найдено
+	expectStatus(t, request(t, srv, 1, http.MethodDelete, location, ""), http.StatusNoContent)
+	expectStatus(t, request(t, srv, 1, http.MethodGet, location, ""), http.StatusNotFound)
+	expectStatus(t, request(t, srv, 1, http.MethodDelete, location, ""), http.StatusNotFound)
+}
+
+func Test_eventHandler_methodNotAllowed(t *testing.T) {
+	srv := newTestServer(t)
+	expectStatus(t, request(t, srv, 1, http.MethodPost, eventsV2Path, `{"date":"2023-05-01","description":"a"}`), http.StatusCreated)
+
+	tests := []struct {
+		method, path string
+		allow        string
+	}{
+		{http.MethodDelete, eventsV2Path, eventsV2Allow},
+		{http.MethodPost, eventsV2Path + "/1", eventV2Allow},
+		{http.MethodGet, "/create_event", http.MethodPost},
+		{http.MethodPost, "/events_for_day", http.MethodGet},
+		{http.MethodPut, trashV2Path, http.MethodGet},
+		{http.MethodPost, freeBusyV2Path, http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			resp := request(t, srv, 1, tt.method, tt.path, "")
+			expectStatus(t, resp, http.StatusMethodNotAllowed)
+
+			if allow := resp.Header.Get("Allow"); allow != tt.allow {
+				t.Errorf("Allow = %q, want %q", allow, tt.allow)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", contentType)
+			}
+		})
+	}
+}
+
+func Test_eventHandler_v2Validation(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		status       int
+	}{
+		{"id not a number", http.MethodGet, eventsV2Path + "/abc", "", http.StatusBadRequest},
+		{"missing event", http.MethodGet, eventsV2Path + "/100", "", http.StatusNotFound},
+		{"range without to", http.MethodGet, eventsV2Path + "?from=2023-05-01", "", http.StatusBadRequest},
+		{"range too wide", http.MethodGet, eventsV2Path + "?from=2023-01-01&to=2024-12-31", "", http.StatusBadRequest},
+		{"bad bounds", http.MethodGet, eventsV2Path + "?from=2023-05-01&to=2023-05-02&bounds=%7B%7D", "", http.StatusBadRequest},
+		{"malformed body", http.MethodPost, eventsV2Path, `{"date":`, http.StatusBadRequest},
+		{"bad date", http.MethodPost, eventsV2Path, `{"date":"01.05.2023","description":"a"}`, http.StatusBadRequest},
+		{"empty patch of missing event", http.MethodPatch, eventsV2Path + "/100", `{}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := request(t, srv, 1, tt.method, tt.path, tt.body)
+			expectStatus(t, resp, tt.status)
+
+			var problem struct {
+				Status int    `json:"status"`
+				Code   string `json:"code"`
+			}
+			resp.decode(t, &problem)
+			if problem.Status != tt.status || problem.Code == "" {
+				t.Errorf("problem = %s", resp.body)
+			}
+		})
+	}
+}
+
+func Test_eventHandler_scheduleConflict(t *testing.T) {
+	srv := newTestServer(t)
+	expectStatus(t, request(t, srv, 1, http.MethodPost, eventsV2Path,
+		`{"start":"2023-05-01T10:00:00Z","end":"2023-05-01T11:00:00Z","description":"busy"}`), http.StatusCreated)
+
+	overlapping := `{"start":"2023-05-01T10:30:00Z","end":"2023-05-01T11:30:00Z","description":"meeting"}`
+
+	// В режиме reject_on_conflict пересечение отклоняется с 409 и не создает событие
+	resp := request(t, srv, 1, http.MethodPost, eventsV2Path+"?reject_on_conflict=true", overlapping)
+	expectStatus(t, resp, http.StatusConflict)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	resp.decode(t, &problem)
+	if problem.Code != "schedule_conflict" {
+		t.Errorf("problem = %s, want code schedule_conflict", resp.body)
+	}
+	expectStatus(t, request(t, srv, 1, http.MethodGet, eventsV2Path+"/2", ""), http.StatusNotFound)
+
+	// Без режима событие создается с предупреждением о пересечении
+	var created struct {
+		Warnings []struct {
+			Code   string `json:"code"`
+			Detail string `json:"detail"`
+		} `json:"warnings"`
+	}
+	resp = request(t, srv, 1, http.MethodPost, eventsV2Path, overlapping)
+	expectStatus(t, resp, http.StatusCreated)
+	resp.decode(t, &created)
+	if len(created.Warnings) != 1 || created.Warnings[0].Code != "schedule_conflict" ||
+		created.Warnings[0].Detail != "user 1 is busy from 2023-05-01T10:30:00Z to 2023-05-01T11:00:00Z" {
+		t.Errorf("warnings = %s", resp.body)
+	}
+
+	// Изменение на свободное время проходит без предупреждений
+	resp = request(t, srv, 1, http.MethodPatch, eventsV2Path+"/2?reject_on_conflict=true",
+		`{"start":"2023-05-01T12:00:00Z","end":"2023-05-01T13:00:00Z"}`)
+	expectStatus(t, resp, http.StatusOK)
+	if bytes.Contains(resp.body, []byte("warnings")) {
+		t.Errorf("PATCH to free time = %s, want no warnings", resp.body)
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/service"
+	"dev11/calendar/pkg/api_helper"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Пути занятости пользователей и поиска свободного времени REST API v2
+	freeBusyV2Path = "/api/v2/freebusy"
+	freeSlotV2Path = "/api/v2/freebusy/slot"
+
+	// Наибольшее количество пользователей в запросе занятости
+	maxFreeBusyUsers = 100
+	// Наибольшая длительность искомого свободного интервала в минутах (неделя)
+	maxSlotMinutes = 7 * 24 * 60
+)
+
+// Занятость пользователей за период: GET /api/v2/freebusy?user_ids=&from=&to=
+func (h *eventHandler) FreeBusyV2(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Получение и валидация пользователей и границ периода
+	userIDs, err := parseUserIDs(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	from, to, bounds, tz, err := parseRangeParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение занятости
+	users, err := h.eventService.FreeBusy(userIDs, from, to, bounds, tz)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Оформление ответа
+	var payload api_helper.JsonResponse
+	payload.Result = struct {
+		Users []service.UserBusy `json:"users"`
+	}{Users: users}
+
+	api_helper.WriteJSON(w, http.StatusOK, payload)
+}
+
+// Первый свободный у всех пользователей интервал заданной длительности:
+// GET /api/v2/freebusy/slot?user_ids=&from=&to=&duration=
+func (h *eventHandler) FreeSlotV2(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Получение и валидация пользователей, границ периода и длительности
+	userIDs, err := parseUserIDs(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	from, to, bounds, tz, err := parseRangeParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	minutes, err := strconv.Atoi(r.URL.Query().Get("duration"))
+	if err != nil || minutes <= 0 || minutes > maxSlotMinutes {
+		writeError(w, model.NewValidationError("duration", fmt.Sprintf("should be a number of minutes from 1 to %d", maxSlotMinutes)))
+		return
+	}
+
+	// Поиск свободного интервала
+	slot, err := h.eventService.FindFreeSlot(userIDs, from, to, bounds, tz, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Оформление ответа
+	var payload api_helper.JsonResponse
+	payload.Result = slot
+
+	api_helper.WriteJSON(w, http.StatusOK, payload)
+}
+
+// Получение списка пользователей user_ids через запятую. Повторы пропускаются
+func parseUserIDs(r *http.Request) ([]int, error) {
+	value := r.URL.Query().Get("user_ids")
+	if value == "" {
+		return nil, model.NewValidationError("user_ids", "is required")
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > maxFreeBusyUsers {
+		return nil, model.NewValidationError("user_ids", fmt.Sprintf("should contain at most %d users", maxFreeBusyUsers))
+	}
+
+	userIDs := make([]int, 0, len(parts))
+	seen := map[int]bool{}
+	for _, part := range parts {
+		userID, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || userID <= 0 {
+			return nil, model.NewValidationError("user_ids", "should be a comma-separated list of positive integers")
+		}
+
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/idempotency"
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/metrics"
+	"dev11/calendar/internal/middleware"
+	"dev11/calendar/internal/repository"
+	"dev11/calendar/internal/service"
+	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
+)
+
+// Секрет подписи токенов тестового сервера
+var testSecret = []byte("secret")
+
+// Наибольшая ширина периода выборок тестового сервера в днях
+const testMaxRangeDays = 366
+
+// Логгер, отбрасывающий записи
+var discardLogger = logger.New(io.Discard, logger.LevelDebug)
+
+// Тестовый сервер с обработчиками событий поверх файлового хранилища во временной директории
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+
+	store, err := storage.New(storage.TypeFile, filepath.Join(dir, "data.json"))
+	if err != nil {
+		t.Fatalf("opening storage: %v", err)
+	}
+
+	eventJournal, err := journal.NewJournal(filepath.Join(dir, "journal.log"), discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	t.Cleanup(func() { eventJournal.Close() })
+
+	repo, err := repository.NewEventRepository(store, eventJournal, discardLogger)
+	if err != nil {
+		t.Fatalf("opening repository: %v", err)
+	}
+
+	eventService := service.NewEventService(repo, bus.NewBus(), discardLogger, testMaxRangeDays)
+	httpMetrics := metrics.NewHTTPMetrics(metrics.NewRegistry())
+
+	mux := http.NewServeMux()
+	NewEventHandler(eventService, discardLogger, httpMetrics, middleware.Auth(testSecret),
+		middleware.Idempotency(idempotency.NewStore(time.Hour, 100))).Register(mux)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// Ответ тестового сервера с прочитанным телом
+type testResponse struct {
+	*http.Response
+	body []byte
+}
+
+// Разбор тела ответа в v
+func (resp testResponse) decode(t *testing.T, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(resp.body, v); err != nil {
+		t.Fatalf("decoding response %s: %v", resp.body, err)
+	}
+}
+
+// Запрос method path с телом body от пользователя userID (без аутентификации при нулевом)
+// и дополнительными заголовками headers парами "имя, значение"
+func request(t *testing.T, srv *httptest.Server, userID int, method, path, body string, headers ...string) testResponse {
+	t.Helper()
+
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != 0 {
+		req.Header.Set("Authorization", "Bearer "+middleware.SignToken(testSecret, userID))
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+
+	return testResponse{Response: resp, body: data}
+}
+
+// Проверка статуса ответа
+func expectStatus(t *testing.T, resp testResponse, status int) {
+	t.Helper()
+
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s: status = %d, want %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, status, resp.body)
+	}
+}
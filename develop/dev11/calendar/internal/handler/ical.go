@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"dev11/calendar/internal/ical"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/api_helper"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Максимальный размер импортируемого календаря
+const maxICSBytes = 10 << 20 // 10 mb
+
+// Выгрузка событий пользователя за период в iCalendar: GET /events.ics?from=&to=
+func (h *eventHandler) ExportICS(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Получение и валидация границ периода
+	from, to, bounds, tz, err := parseRangeParams(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Получение событий
+	events, err := h.eventService.Export(currentUser(r), from, to, bounds, tz)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Сериализация выполняется в буфер, чтобы ошибка не оборвала уже начатый ответ
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, events); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Оформление ответа
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="events.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// Импорт событий из iCalendar пользователю: POST /import_ics?tz=
+// Параметр tz задает пояс для времени без часового пояса
+func (h *eventHandler) ImportICS(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	// Получение и валидация часового пояса
+	tz := r.URL.Query().Get("tz")
+	if err := validateTimeZone(tz); err != nil {
+		writeError(w, err)
+		return
+	}
+	loc, _ := time.LoadLocation(tz)
+
+	// Разбор календаря
+	r.Body = http.MaxBytesReader(w, r.Body, maxICSBytes)
+	events, err := ical.Decode(r.Body, loc)
+	if err != nil {
+		writeError(w, invalidCalendar(err))
+		return
+	}
+
+	// Импорт событий
+	created, updated, err := h.eventService.Import(currentUser(r), events)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = struct {
+		Created int `json:"created"`
+		Updated int `json:"updated"`
+	}{Created: created, Updated: updated}
+
+	// Оформление ответа
+	api_helper.WriteJSON(w, http.StatusOK, payload)
+}
+
+// Ошибка разбора календаря как ошибка валидации тела запроса.
+// Превышение размера тела остается как есть
+func invalidCalendar(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+
+	return model.NewValidationError("body", fmt.Sprintf("should be a valid iCalendar: %v", err))
+}
@@ -10,4 +10,36 @@ type IEventHandler interface {
 	GetForDay(w http.ResponseWriter, r *http.Request)
 	GetForWeek(w http.ResponseWriter, r *http.Request)
 	GetForMonth(w http.ResponseWriter, r *http.Request)
+	GetForRange(w http.ResponseWriter, r *http.Request)
+	GetByID(w http.ResponseWriter, r *http.Request)
+	ExportICS(w http.ResponseWriter, r *http.Request)
+	ImportICS(w http.ResponseWriter, r *http.Request)
+	Batch(w http.ResponseWriter, r *http.Request)
+	EventsV2(w http.ResponseWriter, r *http.Request)
+	EventV2(w http.ResponseWriter, r *http.Request)
+	TrashV2(w http.ResponseWriter, r *http.Request)
+	TrashItemV2(w http.ResponseWriter, r *http.Request)
+	FreeBusyV2(w http.ResponseWriter, r *http.Request)
+	FreeSlotV2(w http.ResponseWriter, r *http.Request)
+}
+
+type IMetricsHandler interface {
+	Register(routes *http.ServeMux)
+	Metrics(w http.ResponseWriter, r *http.Request)
+}
+
+type ISnapshotHandler interface {
+	Register(routes *http.ServeMux)
+	Status(w http.ResponseWriter, r *http.Request)
+}
+
+type IOpenAPIHandler interface {
+	Register(routes *http.ServeMux)
+	Document(w http.ResponseWriter, r *http.Request)
+}
+
+type IStreamHandler interface {
+	Register(routes *http.ServeMux)
+	Stream(w http.ResponseWriter, r *http.Request)
+	Close()
 }
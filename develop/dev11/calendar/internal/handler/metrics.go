@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"dev11/calendar/internal/metrics"
+	"dev11/calendar/internal/middleware"
+	"dev11/calendar/pkg/logger"
+	"net/http"
+)
+
+// Хэндлер метрик в текстовом формате Prometheus
+type metricsHandler struct {
+	registry *metrics.Registry
+	logger   logger.ILogger
+}
+
+// Конструктор хэндлера метрик
+func NewMetricsHandler(registry *metrics.Registry, logger logger.ILogger) IMetricsHandler {
+	return &metricsHandler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// Регистрация обработчиков в роутере router. Метрики отдаются без аутентификации для сборщика
+func (h *metricsHandler) Register(router *http.ServeMux) {
+	router.Handle("/metrics", middleware.Log(h.logger, "/metrics")(http.HandlerFunc(h.Metrics)))
+}
+
+// Вывод всех метрик
+func (h *metricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	h.registry.WriteTo(w)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"dev11/calendar/internal/middleware"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/openapi"
+	"dev11/calendar/pkg/api_helper"
+	"dev11/calendar/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Хэндлер документа OpenAPI
+type openAPIHandler struct {
+	logger logger.ILogger
+}
+
+// Конструктор хэндлера документа OpenAPI
+func NewOpenAPIHandler(logger logger.ILogger) IOpenAPIHandler {
+	return &openAPIHandler{
+		logger: logger,
+	}
+}
+
+// Регистрация обработчиков в роутере router. Документ отдается без аутентификации
+func (h *openAPIHandler) Register(router *http.ServeMux) {
+	router.Handle("/openapi.json", middleware.Log(h.logger, "/openapi.json")(http.HandlerFunc(h.Document)))
+}
+
+// Вывод документа OpenAPI
+func (h *openAPIHandler) Document(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openapi.Document())
+}
+
+// Чтение тела запроса в dto с валидацией по схеме schema документа OpenAPI
+func readValidJSON(w http.ResponseWriter, r *http.Request, schema string, dto any) error {
+	body, err := api_helper.ReadBody(w, r)
+	if err != nil {
+		return err
+	}
+
+	if err := openapi.ValidateJSON(schema, body); err != nil {
+		return err
+	}
+
+	// Схема уже проверена, поэтому ошибка означает несоответствие типа Go, например переполнение числа
+	if err := json.Unmarshal(body, dto); err != nil {
+		return model.NewValidationError("body", fmt.Sprintf("can't be decoded: %v", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"dev11/calendar/internal/metrics"
+	"dev11/calendar/internal/middleware"
+	"dev11/calendar/internal/snapshot"
+	"dev11/calendar/pkg/api_helper"
+	"dev11/calendar/pkg/logger"
+	"net/http"
+)
+
+// Хэндлер состояния снимков хранилища
+type snapshotHandler struct {
+	saver   snapshot.ISaver
+	logger  logger.ILogger
+	metrics *metrics.HTTPMetrics
+}
+
+// Конструктор хэндлера состояния снимков
+func NewSnapshotHandler(saver snapshot.ISaver, logger logger.ILogger, metrics *metrics.HTTPMetrics) ISnapshotHandler {
+	return &snapshotHandler{
+		saver:   saver,
+		logger:  logger,
+		metrics: metrics,
+	}
+}
+
+// Регистрация обработчиков в роутере router
+func (h *snapshotHandler) Register(router *http.ServeMux) {
+	route := "/snapshot_status"
+	router.Handle(route, middleware.Log(h.logger, route)(middleware.Metrics(h.metrics, route)(http.HandlerFunc(h.Status))))
+}
+
+// Время последнего снимка и ошибка последней попытки
+func (h *snapshotHandler) Status(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = h.saver.Status()
+
+	// Оформление ответа
+	api_helper.WriteJSON(w, http.StatusOK, payload)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/metrics"
+	"dev11/calendar/internal/middleware"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/api_helper"
+	"dev11/calendar/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Путь потока изменений событий
+	streamPath = "/events/stream"
+	// Период комментария, не дающего прокси закрыть простаивающее соединение
+	heartbeatInterval = 15 * time.Second
+	// Пауза перед переподключением клиента EventSource в миллисекундах
+	reconnectDelay = 3000
+)
+
+// Хэндлер потока изменений событий в формате Server-Sent Events
+type streamHandler struct {
+	bus     bus.IBus
+	logger  logger.ILogger
+	metrics *metrics.HTTPMetrics
+	// Промежуточный слой аутентификации
+	auth func(http.Handler) http.Handler
+	// Канал, закрываемый при остановке сервера, чтобы завершить открытые потоки
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Конструктор хэндлера потока изменений
+func NewStreamHandler(bus bus.IBus, logger logger.ILogger, metrics *metrics.HTTPMetrics,
+	auth func(http.Handler) http.Handler) IStreamHandler {
+	return &streamHandler{
+		bus:     bus,
+		logger:  logger,
+		metrics: metrics,
+		auth:    auth,
+		done:    make(chan struct{}),
+	}
+}
+
+// Регистрация обработчиков в роутере router. Поток требует аутентификации
+func (h *streamHandler) Register(router *http.ServeMux) {
+	router.Handle(streamPath, middleware.Log(h.logger, streamPath)(middleware.Metrics(h.metrics, streamPath)(h.auth(http.HandlerFunc(h.Stream)))))
+}
+
+// Завершение открытых потоков и отказ в новых. Вызывается при остановке сервера,
+// который иначе ждал бы окончания бесконечных потоков
+func (h *streamHandler) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// Поток изменений событий пользователя: GET /events/stream.
+// Каждое изменение передается сообщением с номером изменения в id, типом в event и JSON в data.
+// Клиент, переподключившийся с заголовком Last-Event-ID, получает пропущенные изменения,
+// если они еще хранятся в истории шины. Поток закрывается сервером, если клиент не успевает
+// читать изменения, и при остановке сервиса
+func (h *streamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	// Обработка несоответствия метода запроса
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	// Номер последнего полученного клиентом изменения
+	var lastID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(header, 10, 64); err != nil {
+			writeError(w, model.NewValidationError("Last-Event-ID", "header should be a change ID"))
+			return
+		}
+	}
+
+	// Подписка на изменения событий, которые пользователь организует или на которые приглашен.
+	// При остановке сервиса новые потоки не открываются
+	userID := currentUser(r)
+	var sub bus.ISubscription
+	err := bus.ErrClosed
+	if !h.closed() {
+		sub, err = h.bus.Subscribe(lastID, func(change bus.Change) bool { return change.VisibleTo(userID) })
+	}
+	if err != nil {
+		api_helper.WriteProblem(w, api_helper.Problem{
+			Status: http.StatusServiceUnavailable,
+			Code:   "shutting_down",
+			Detail: "service is shutting down",
+		})
+		return
+	}
+	defer sub.Close()
+
+	// Поток не ограничен таймаутом записи сервера
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return
+			}
+			if err := writeChange(w, change); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Признак остановки сервера
+func (h *streamHandler) closed() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Запись изменения change сообщением потока
+func writeChange(w io.Writer, change bus.Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+	return err
+}
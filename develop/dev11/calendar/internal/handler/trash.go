@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/api_helper"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Путь корзины удаленных событий REST API v2
+	trashV2Path = "/api/v2/trash"
+	// Суффикс пути восстановления события из корзины
+	restoreSuffix = "/restore"
+)
+
+// Корзина: GET /api/v2/trash - удаленные события пользователя
+func (h *eventHandler) TrashV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	events, err := h.eventService.GetDeleted(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Возвращаемое значение
+	var payload api_helper.JsonResponse
+	payload.Result = struct {
+		Events []model.Event `json:"events"`
+	}{Events: events}
+
+	// Оформление ответа
+	api_helper.WriteJSON(w, http.StatusOK, payload)
+}
+
+// Событие в корзине: POST /api/v2/trash/{id}/restore - восстановление,
+// DELETE /api/v2/trash/{id} - окончательное удаление
+func (h *eventHandler) TrashItemV2(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, trashV2Path+"/")
+	rawID, restore := strings.CutSuffix(path, restoreSuffix)
+
+	// Получение ID из пути
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		writeError(w, model.NewValidationError("id", "in path should be a positive integer"))
+		return
+	}
+
+	if restore {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+
+		if err := h.eventService.Restore(currentUser(r), id); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		// Восстановленное событие
+		h.getV2(w, r, id)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodDelete)
+		return
+	}
+
+	if err := h.eventService.Purge(currentUser(r), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
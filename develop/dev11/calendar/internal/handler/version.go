@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"dev11/calendar/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Ожидаемая версия изменяемого события: из заголовка If-Match, а при его отсутствии -
+// из поля version тела запроса. Признак ifMatch означает, что версия задана заголовком.
+// If-Match: * совпадает с любой версией
+func expectedVersion(r *http.Request, bodyVersion int) (version int, ifMatch bool, err error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return bodyVersion, false, nil
+	}
+
+	if header == "*" {
+		return 0, true, nil
+	}
+
+	// Версия сравнивается строго, поэтому слабый тег W/"..." не подходит
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, true, model.NewValidationError("If-Match", "header should contain an event ETag")
+	}
+
+	version, err = strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return 0, true, model.NewValidationError("If-Match", "header should contain an event ETag")
+	}
+
+	return version, true, nil
+}
+
+// Заголовки ответа с тегом версии события
+func eventHeaders(event model.Event) http.Header {
+	headers := http.Header{}
+	headers.Set("ETag", event.ETag())
+
+	return headers
+}
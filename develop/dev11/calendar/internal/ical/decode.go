@@ -0,0 +1,411 @@
+package ical
+
+import (
+	"bufio"
+	"dev11/calendar/internal/model"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Описание события без SUMMARY
+const untitled = "(no summary)"
+
+// Свойство контента iCalendar: NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Разбор событий VEVENT из календаря iCalendar (RFC 5545).
+// Время без часового пояса ("плавающее") отсчитывается в поясе loc.
+// Отдельно измененное повторение (VEVENT с RECURRENCE-ID) становится самостоятельным событием,
+// а его дата исключается из серии с тем же UID
+func Decode(r io.Reader, loc *time.Location) ([]model.Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events []model.Event
+		// Даты RECURRENCE-ID по UID серии
+		overridden = map[string][]string{}
+		// Свойства текущего VEVENT, nil вне VEVENT
+		props []property
+		// Имя вложенного компонента внутри VEVENT (например, VALARM), свойства которого пропускаются
+		nested string
+	)
+
+	for n, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch {
+		case nested != "":
+			if prop.name == "END" && strings.EqualFold(prop.value, nested) {
+				nested = ""
+			}
+
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			props = []property{}
+
+		case props == nil:
+			// Свойства вне VEVENT (VCALENDAR, VTIMEZONE) не нужны
+
+		case prop.name == "BEGIN":
+			nested = prop.value
+
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			event, recurrenceID, err := buildEvent(props, loc)
+			if err != nil {
+				return nil, fmt.Errorf("event ending at line %d: %w", n+1, err)
+			}
+
+			if recurrenceID != "" {
+				overridden[event.UID] = append(overridden[event.UID], recurrenceID)
+				event.UID += "#" + recurrenceID
+			}
+
+			events = append(events, event)
+			props = nil
+
+		default:
+			props = append(props, prop)
+		}
+	}
+
+	if props != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+
+	// Исключение измененных повторений из их серий
+	for i := range events {
+		if dates, ok := overridden[events[i].UID]; ok && events[i].IsRecurring() {
+			events[i].ExDates = append(events[i].ExDates, dates...)
+		}
+	}
+
+	return events, nil
+}
+
+// Чтение строк с объединением перенесенных (RFC 5545, 3.1)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// Строка продолжения начинается с пробела или табуляции
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading calendar: %w", err)
+	}
+
+	return lines, nil
+}
+
+// Разбор строки контента. Двоеточия и точки с запятой внутри кавычек параметров не считаются разделителями
+func parseLine(line string) (property, error) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+
+	if colon < 0 {
+		return property{}, fmt.Errorf("malformed content line %q", line)
+	}
+
+	prop := property{params: map[string]string{}, value: line[colon+1:]}
+
+	parts := splitOutsideQuotes(line[:colon], ';')
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop, nil
+}
+
+// Разбиение строки по разделителю sep вне кавычек
+func splitOutsideQuotes(s string, sep rune) []string {
+	parts := []string{}
+	inQuotes := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// Сборка события из свойств VEVENT. Возвращает также дату RECURRENCE-ID, если она задана
+func buildEvent(props []property, loc *time.Location) (model.Event, string, error) {
+	var (
+		event    model.Event
+		start    *property
+		end      *property
+		duration *property
+		rrule    *property
+		exdates  []property
+		recurID  *property
+	)
+
+	for i := range props {
+		prop := &props[i]
+		switch prop.name {
+		case "UID":
+			event.UID = unescapeText(prop.value)
+		case "SUMMARY":
+			event.Description = unescapeText(prop.value)
+		case "DTSTART":
+			start = prop
+		case "DTEND":
+			end = prop
+		case "DURATION":
+			duration = prop
+		case "RRULE":
+			rrule = prop
+		case "EXDATE":
+			exdates = append(exdates, *prop)
+		case "RECURRENCE-ID":
+			recurID = prop
+		}
+	}
+
+	if event.UID == "" {
+		return model.Event{}, "", errors.New("UID is required")
+	}
+
+	if start == nil {
+		return model.Event{}, "", errors.New("DTSTART is required")
+	}
+
+	if event.Description == "" {
+		event.Description = untitled
+	}
+
+	// Начало события
+	startTime, allDay, err := parseDateTime(*start, loc)
+	if err != nil {
+		return model.Event{}, "", fmt.Errorf("DTSTART: %w", err)
+	}
+
+	// Окончание: DTEND, DURATION, либо по умолчанию день для даты и нулевая длительность для времени
+	var endTime time.Time
+	switch {
+	case end != nil:
+		if endTime, _, err = parseDateTime(*end, loc); err != nil {
+			return model.Event{}, "", fmt.Errorf("DTEND: %w", err)
+		}
+	case duration != nil:
+		d, err := parseDuration(duration.value)
+		if err != nil {
+			return model.Event{}, "", fmt.Errorf("DURATION: %w", err)
+		}
+		endTime = startTime.Add(d)
+	case allDay:
+		endTime = startTime.AddDate(0, 0, 1)
+	default:
+		endTime = startTime
+	}
+
+	if endTime.Before(startTime) {
+		return model.Event{}, "", errors.New("DTEND is before DTSTART")
+	}
+
+	// Событие на один день хранится датой, многодневное и со временем - интервалом
+	if allDay && !endTime.After(startTime.AddDate(0, 0, 1)) {
+		event.Date = startTime.Format(model.DateLayout)
+	} else {
+		event.Start = startTime.Format(model.TimeLayout)
+		event.End = endTime.Format(model.TimeLayout)
+		if tzid := start.params["TZID"]; tzid != "" {
+			event.TimeZone = tzid
+		} else if allDay && loc != time.UTC {
+			event.TimeZone = loc.String()
+		}
+		if err := event.FillDate(); err != nil {
+			return model.Event{}, "", err
+		}
+	}
+
+	// Правило повторения и исключенные даты
+	if rrule != nil {
+		rule, err := parseRule(rrule.value, loc)
+		if err != nil {
+			return model.Event{}, "", fmt.Errorf("RRULE: %w", err)
+		}
+		event.Recurrence = &rule
+
+		for _, exdate := range exdates {
+			for _, value := range strings.Split(exdate.value, ",") {
+				exdate.value = value
+				t, _, err := parseDateTime(exdate, loc)
+				if err != nil {
+					return model.Event{}, "", fmt.Errorf("EXDATE: %w", err)
+				}
+				event.ExDates = append(event.ExDates, t.In(startTime.Location()).Format(model.DateLayout))
+			}
+		}
+	}
+
+	// Дата измененного повторения
+	var recurrenceID string
+	if recurID != nil {
+		t, _, err := parseDateTime(*recurID, loc)
+		if err != nil {
+			return model.Event{}, "", fmt.Errorf("RECURRENCE-ID: %w", err)
+		}
+		recurrenceID = t.In(startTime.Location()).Format(model.DateLayout)
+	}
+
+	return event, recurrenceID, nil
+}
+
+// Разбор даты или времени свойства prop. Возвращает признак значения-даты
+func parseDateTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	value := prop.value
+
+	// Дата
+	if prop.params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+
+	// Время в UTC
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+
+	// Местное время в поясе TZID или "плавающее"
+	if tzid := prop.params["TZID"]; tzid != "" {
+		tzLoc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = tzLoc
+	}
+
+	t, err := time.ParseInLocation(localLayout, value, loc)
+	return t, false, err
+}
+
+// Длительность в формате RFC 5545 (например, PT1H30M, P1D, P2W)
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// Разбор длительности DURATION
+func parseDuration(value string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(value)
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("malformed duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+2])
+		d += time.Duration(n) * unit
+	}
+
+	if m[1] == "-" {
+		return 0, errors.New("negative duration is not supported")
+	}
+
+	return d, nil
+}
+
+// Разбор правила RRULE. Поддерживаются FREQ, INTERVAL, BYDAY без порядковых номеров, COUNT и UNTIL;
+// WKST игнорируется
+func parseRule(value string, loc *time.Location) (model.Recurrence, error) {
+	var rule model.Recurrence
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToLower(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return model.Recurrence{}, fmt.Errorf("malformed INTERVAL %q", val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return model.Recurrence{}, fmt.Errorf("malformed COUNT %q", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, allDay, err := parseDateTime(property{value: val}, loc)
+			if err != nil {
+				return model.Recurrence{}, fmt.Errorf("malformed UNTIL %q", val)
+			}
+			if allDay {
+				rule.Until = until.Format(model.DateLayout)
+			} else {
+				rule.Until = until.Format(model.TimeLayout)
+			}
+		case "BYDAY":
+			rule.ByDay = strings.Split(strings.ToUpper(val), ",")
+		case "WKST":
+		default:
+			return model.Recurrence{}, fmt.Errorf("unsupported part %q", key)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return model.Recurrence{}, err
+	}
+
+	return rule, nil
+}
+
+// Снятие экранирования текстового значения
+func unescapeText(text string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(text)
+}
@@ -0,0 +1,203 @@
+package ical
+
+import (
+	"bufio"
+	"dev11/calendar/internal/model"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// Формат даты iCalendar (VALUE=DATE)
+	dateLayout = "20060102"
+	// Формат местного времени iCalendar
+	localLayout = "20060102T150405"
+	// Формат времени в UTC iCalendar
+	utcLayout = "20060102T150405Z"
+
+	// Максимальная длина строки в октетах, после которой строка переносится (RFC 5545, 3.1)
+	maxLineOctets = 75
+
+	// Идентификатор продукта, формирующего календарь
+	prodID = "-//dev11//calendar//EN"
+)
+
+// Сериализация событий events в календарь iCalendar (RFC 5545).
+// Серии выгружаются одним VEVENT с RRULE и EXDATE, отдельно измененные повторения - самостоятельными VEVENT
+func Encode(w io.Writer, events []model.Event) error {
+	enc := &encoder{w: bufio.NewWriter(w)}
+
+	enc.line("BEGIN:VCALENDAR")
+	enc.line("VERSION:2.0")
+	enc.line("PRODID:" + prodID)
+	enc.line("CALSCALE:GREGORIAN")
+
+	stamp := time.Now().UTC().Format(utcLayout)
+	for _, event := range events {
+		if err := enc.event(event, stamp); err != nil {
+			return err
+		}
+	}
+
+	enc.line("END:VCALENDAR")
+
+	if enc.err != nil {
+		return enc.err
+	}
+
+	return enc.w.Flush()
+}
+
+// Сериализатор календаря. Первая ошибка записи сохраняется, последующие записи пропускаются
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// Сериализация одного события
+func (enc *encoder) event(event model.Event, stamp string) error {
+	enc.line("BEGIN:VEVENT")
+	enc.line("UID:" + escapeText(event.GetUID()))
+	enc.line("DTSTAMP:" + stamp)
+
+	if event.IsAllDay() {
+		start, err := time.Parse(model.DateLayout, event.Date)
+		if err != nil {
+			return fmt.Errorf("parsing date of event %d: %w", event.ID, err)
+		}
+		enc.line("DTSTART;VALUE=DATE:" + start.Format(dateLayout))
+		enc.line("DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format(dateLayout))
+	} else {
+		start, end, err := event.Interval(time.UTC)
+		if err != nil {
+			return fmt.Errorf("parsing interval of event %d: %w", event.ID, err)
+		}
+
+		loc, err := event.Location(time.UTC)
+		if err != nil {
+			return fmt.Errorf("loading timezone of event %d: %w", event.ID, err)
+		}
+
+		enc.line("DTSTART" + formatTime(start, loc, event.TimeZone))
+		enc.line("DTEND" + formatTime(end, loc, event.TimeZone))
+	}
+
+	enc.line("SUMMARY:" + escapeText(event.Description))
+
+	if event.IsRecurring() {
+		enc.line("RRULE:" + formatRule(*event.Recurrence))
+
+		for _, date := range event.ExDates {
+			exdate, err := exdateValue(event, date)
+			if err != nil {
+				return err
+			}
+			enc.line("EXDATE" + exdate)
+		}
+	}
+
+	enc.line("END:VEVENT")
+
+	return nil
+}
+
+// Запись строки контента с переносом длинных строк и окончанием CRLF
+func (enc *encoder) line(content string) {
+	if enc.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(content) > limit {
+		// Перенос не должен разрезать многобайтовый символ UTF-8
+		cut := limit
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		if _, enc.err = enc.w.WriteString(content[:cut] + "\r\n "); enc.err != nil {
+			return
+		}
+		content = content[cut:]
+
+		// Строка продолжения начинается с пробела, который тоже занимает октет
+		limit = maxLineOctets - 1
+	}
+
+	_, enc.err = enc.w.WriteString(content + "\r\n")
+}
+
+// Параметры и значение момента t: в поясе tzid, если он задан, иначе в UTC
+func formatTime(t time.Time, loc *time.Location, tzid string) string {
+	if tzid != "" {
+		return ";TZID=" + tzid + ":" + t.In(loc).Format(localLayout)
+	}
+	return ":" + t.UTC().Format(utcLayout)
+}
+
+// Значение EXDATE для исключенной даты date серии event
+func exdateValue(event model.Event, date string) (string, error) {
+	day, err := time.Parse(model.DateLayout, date)
+	if err != nil {
+		return "", fmt.Errorf("parsing exdate of event %d: %w", event.ID, err)
+	}
+
+	if event.IsAllDay() {
+		return ";VALUE=DATE:" + day.Format(dateLayout), nil
+	}
+
+	// Для события со временем исключается момент начала повторения в эту дату
+	loc, err := event.Location(time.UTC)
+	if err != nil {
+		return "", fmt.Errorf("loading timezone of event %d: %w", event.ID, err)
+	}
+
+	start, _, err := event.Interval(time.UTC)
+	if err != nil {
+		return "", fmt.Errorf("parsing interval of event %d: %w", event.ID, err)
+	}
+	start = start.In(loc)
+
+	exdate := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+
+	return formatTime(exdate, loc, event.TimeZone), nil
+}
+
+// Значение RRULE для правила повторения rule
+func formatRule(rule model.Recurrence) string {
+	parts := []string{"FREQ=" + strings.ToUpper(rule.Freq)}
+
+	if rule.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", rule.Interval))
+	}
+
+	if len(rule.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(rule.ByDay, ","))
+	}
+
+	if rule.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", rule.Count))
+	}
+
+	// Граница-дата выгружается датой, граница-момент - временем в UTC
+	if day, err := time.Parse(model.DateLayout, rule.Until); err == nil {
+		parts = append(parts, "UNTIL="+day.Format(dateLayout))
+	} else if until, err := time.Parse(model.TimeLayout, rule.Until); err == nil {
+		parts = append(parts, "UNTIL="+until.UTC().Format(utcLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Экранирование текстового значения (RFC 5545, 3.3.11)
+func escapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
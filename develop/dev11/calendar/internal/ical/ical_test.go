@@ -0,0 +1,125 @@
+package ical
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/model"
+)
+
+func Test_Encode_Decode_round_trip(t *testing.T) {
+	events := []model.Event{
+		{
+			UID:         "all-day@test",
+			Date:        "2023-05-01",
+			Description: "Праздник; выходной, без \\ работы\nвообще",
+		},
+		{
+			UID:         "standup@test",
+			Date:        "2023-05-01",
+			Start:       "2023-05-01T09:00:00+03:00",
+			End:         "2023-05-01T09:15:00+03:00",
+			TimeZone:    "Europe/Moscow",
+			Recurrence:  &model.Recurrence{Freq: model.FreqWeekly, Interval: 2, ByDay: []string{"MO", "WE"}, Until: "2023-06-30"},
+			ExDates:     []string{"2023-05-03"},
+			Description: strings.Repeat("длинное описание ", 10),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, events); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	// Строки не длиннее 75 октетов
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line is longer than %d octets: %q", maxLineOctets, line)
+		}
+	}
+
+	decoded, err := Decode(&buf, time.UTC)
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, events) {
+		t.Errorf("expected events: %+v, got: %+v", events, decoded)
+	}
+}
+
+func Test_Decode_external_calendar(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Berlin",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:retro@example.com",
+		"DTSTART;TZID=Europe/Berlin:20230505T160000",
+		"DURATION:PT1H",
+		"RRULE:FREQ=WEEKLY;COUNT=4;WKST=MO",
+		"SUMMARY:Ретро",
+		"  спринта",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:retro@example.com",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20230512T160000",
+		"DTSTART;TZID=Europe/Berlin:20230512T170000",
+		"DTEND;TZID=Europe/Berlin:20230512T180000",
+		"SUMMARY:Ретро (перенос)",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Decode(strings.NewReader(calendar), time.UTC)
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	expected := []model.Event{
+		{
+			UID:         "retro@example.com",
+			Date:        "2023-05-05",
+			Start:       "2023-05-05T16:00:00+02:00",
+			End:         "2023-05-05T17:00:00+02:00",
+			TimeZone:    "Europe/Berlin",
+			Recurrence:  &model.Recurrence{Freq: model.FreqWeekly, Count: 4},
+			ExDates:     []string{"2023-05-12"},
+			Description: "Ретро спринта",
+		},
+		{
+			UID:         "retro@example.com#2023-05-12",
+			Date:        "2023-05-12",
+			Start:       "2023-05-12T17:00:00+02:00",
+			End:         "2023-05-12T18:00:00+02:00",
+			TimeZone:    "Europe/Berlin",
+			Description: "Ретро (перенос)",
+		},
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events: %+v, got: %+v", expected, events)
+	}
+}
+
+func Test_Decode_errors(t *testing.T) {
+	for name, calendar := range map[string]string{
+		"without uid":     "BEGIN:VEVENT\r\nDTSTART:20230501T100000Z\r\nEND:VEVENT",
+		"without dtstart": "BEGIN:VEVENT\r\nUID:x\r\nEND:VEVENT",
+		"unknown tzid":    "BEGIN:VEVENT\r\nUID:x\r\nDTSTART;TZID=Nowhere:20230501T100000\r\nEND:VEVENT",
+		"ordinal byday":   "BEGIN:VEVENT\r\nUID:x\r\nDTSTART:20230501T100000Z\r\nRRULE:FREQ=MONTHLY;BYDAY=1MO\r\nEND:VEVENT",
+		"unterminated":    "BEGIN:VEVENT\r\nUID:x\r\nDTSTART:20230501T100000Z",
+	} {
+		if _, err := Decode(strings.NewReader(calendar), time.UTC); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
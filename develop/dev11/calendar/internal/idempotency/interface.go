@@ -0,0 +1,8 @@
+package idempotency
+
+// Хранилище ключей идемпотентности
+type IStore interface {
+	Begin(key, fingerprint string) (*Response, error)
+	Finish(key string, response Response)
+	Abort(key string)
+}
@@ -0,0 +1,133 @@
+package idempotency
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// Ошибка повторного использования ключа с другим запросом
+	ErrKeyReused = errors.New("idempotency key is already used with a different request")
+	// Ошибка повтора запроса, первая попытка которого еще обрабатывается
+	ErrInProgress = errors.New("request with this idempotency key is still in progress")
+	// Ошибка заполнения хранилища ключами обрабатываемых запросов, которые нельзя вытеснить
+	ErrStoreFull = errors.New("too many requests with idempotency keys are in progress")
+)
+
+// Сохраненный ответ на запрос
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Запись о ключе: отпечаток запроса и ответ на него. Ответ отсутствует, пока запрос обрабатывается.
+// element - место завершенного ключа в очереди вытеснения
+type entry struct {
+	fingerprint string
+	response    *Response
+	expires     time.Time
+	element     *list.Element
+}
+
+// Хранилище ключей идемпотентности в памяти процесса. Ключ хранится ttl с момента ответа на запрос,
+// после чего запрос с тем же ключом выполняется заново. Хранится не больше maxEntries ключей:
+// при заполнении новый ключ вытесняет завершенный ключ с самым ранним ответом. Завершенные ключи
+// упорядочены по времени ответа, поэтому истекшие ключи удаляются из начала очереди
+type store struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*entry
+	// Ключи завершенных запросов в порядке ответа на них
+	finished *list.List
+	now      func() time.Time
+}
+
+// Конструктор хранилища не больше чем maxEntries ключей со сроком хранения ttl
+func NewStore(ttl time.Duration, maxEntries int) IStore {
+	return &store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[string]*entry{},
+		finished:   list.New(),
+		now:        time.Now,
+	}
+}
+
+// Начало обработки запроса с ключом key и отпечатком fingerprint.
+// Для нового ключа возвращает nil: запрос выполняется, а его результат передается в Finish или Abort.
+// Для повтора завершенного запроса возвращает сохраненный ответ. Если ключ использован с другим
+// отпечатком, возвращает ErrKeyReused, а если первый запрос еще обрабатывается - ErrInProgress.
+// Если хранилище заполнено обрабатываемыми запросами, возвращает ErrStoreFull
+func (s *store) Begin(key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(s.now())
+
+	if e, ok := s.entries[key]; ok {
+		switch {
+		case e.fingerprint != fingerprint:
+			return nil, ErrKeyReused
+		case e.response == nil:
+			return nil, ErrInProgress
+		default:
+			return e.response, nil
+		}
+	}
+
+	// Вытеснение завершенного ключа с самым ранним ответом
+	if len(s.entries) >= s.maxEntries {
+		oldest := s.finished.Front()
+		if oldest == nil {
+			return nil, ErrStoreFull
+		}
+		s.remove(oldest.Value.(string))
+	}
+
+	s.entries[key] = &entry{fingerprint: fingerprint}
+	return nil, nil
+}
+
+// Сохранение ответа response на запрос с ключом key
+func (s *store) Finish(key string, response Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		e.response = &response
+		e.expires = s.now().Add(s.ttl)
+		e.element = s.finished.PushBack(key)
+	}
+}
+
+// Освобождение ключа key запроса, ответ на который не сохраняется, чтобы запрос можно было повторить
+func (s *store) Abort(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		delete(s.entries, key)
+	}
+}
+
+// Удаление истекших ключей из начала очереди завершенных. Ключи обрабатываемых запросов не удаляются
+func (s *store) sweep(now time.Time) {
+	for front := s.finished.Front(); front != nil; front = s.finished.Front() {
+		key := front.Value.(string)
+		if now.Before(s.entries[key].expires) {
+			return
+		}
+		s.remove(key)
+	}
+}
+
+// Удаление завершенного ключа key
+func (s *store) remove(key string) {
+	s.finished.Remove(s.entries[key].element)
+	delete(s.entries, key)
+}
@@ -0,0 +1,125 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Хранилище не больше чем maxEntries ключей с управляемым временем
+func newTestStore(ttl time.Duration, maxEntries int) (*store, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewStore(ttl, maxEntries).(*store)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func Test_store_Begin(t *testing.T) {
+	s, now := newTestStore(time.Hour, 10)
+	created := Response{Status: http.StatusCreated, Body: []byte(`{"result":{"id":1}}`)}
+
+	if resp, err := s.Begin("k", "a"); resp != nil || err != nil {
+		t.Fatalf("Begin() new key = %v, %v, want nil, nil", resp, err)
+	}
+
+	// Пока первый запрос обрабатывается, повтор отклоняется
+	if _, err := s.Begin("k", "a"); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Begin() in progress error = %v, want ErrInProgress", err)
+	}
+
+	s.Finish("k", created)
+
+	// Повтор получает сохраненный ответ, другой запрос с тем же ключом отклоняется
+	if resp, err := s.Begin("k", "a"); err != nil || resp == nil || resp.Status != created.Status {
+		t.Errorf("Begin() replay = %v, %v, want saved response", resp, err)
+	}
+	if _, err := s.Begin("k", "b"); !errors.Is(err, ErrKeyReused) {
+		t.Errorf("Begin() mismatch error = %v, want ErrKeyReused", err)
+	}
+
+	// По истечении срока ключ используется заново
+	*now = now.Add(time.Hour)
+	if resp, err := s.Begin("k", "b"); resp != nil || err != nil {
+		t.Errorf("Begin() expired key = %v, %v, want nil, nil", resp, err)
+	}
+}
+
+func Test_store_Abort(t *testing.T) {
+	s, _ := newTestStore(time.Hour, 10)
+
+	s.Begin("k", "a")
+	s.Abort("k")
+
+	// После неудачной попытки запрос выполняется заново
+	if resp, err := s.Begin("k", "a"); resp != nil || err != nil {
+		t.Errorf("Begin() after Abort = %v, %v, want nil, nil", resp, err)
+	}
+
+	// Завершенный запрос не освобождается
+	s.Finish("k", Response{Status: http.StatusOK})
+	s.Abort("k")
+	if resp, _ := s.Begin("k", "a"); resp == nil {
+		t.Error("Begin() after Finish and Abort: expected saved response")
+	}
+}
+
+func Test_store_sweep(t *testing.T) {
+	s, now := newTestStore(time.Minute, 10)
+
+	s.Begin("done", "a")
+	s.Finish("done", Response{Status: http.StatusOK})
+	s.Begin("pending", "a")
+
+	*now = now.Add(2 * time.Minute)
+	s.Begin("other", "a")
+
+	if _, ok := s.entries["done"]; ok {
+		t.Error("expected expired key to be removed")
+	}
+	if _, ok := s.entries["pending"]; !ok {
+		t.Error("expected key of request in progress to be kept")
+	}
+}
+
+func Test_store_evict(t *testing.T) {
+	s, now := newTestStore(time.Hour, 2)
+
+	// Ключи завершены в порядке first, second
+	for _, key := range []string{"second", "first"} {
+		s.Begin(key, "a")
+	}
+	s.Finish("first", Response{Status: http.StatusOK})
+	*now = now.Add(time.Minute)
+	s.Finish("second", Response{Status: http.StatusOK})
+
+	// Новый ключ вытесняет ключ с самым ранним ответом
+	if resp, err := s.Begin("third", "a"); resp != nil || err != nil {
+		t.Fatalf("Begin() over full store = %v, %v, want nil, nil", resp, err)
+	}
+	if _, ok := s.entries["first"]; ok {
+		t.Error("expected the oldest finished key to be evicted")
+	}
+	if resp, _ := s.Begin("second", "a"); resp == nil {
+		t.Error("expected the newer finished key to be kept")
+	}
+
+	// Обрабатываемые запросы не вытесняются
+	s.Finish("third", Response{Status: http.StatusOK})
+	s.Begin("fourth", "a")
+	if _, err := s.Begin("fifth", "a"); err != nil {
+		t.Fatalf("Begin() evicting the last finished key error = %v", err)
+	}
+	if _, err := s.Begin("sixth", "a"); !errors.Is(err, ErrStoreFull) {
+		t.Errorf("Begin() over keys in progress error = %v, want ErrStoreFull", err)
+	}
+	if len(s.entries) != 2 || s.finished.Len() != 0 {
+		t.Errorf("store has %d keys and %d finished, want 2 and 0", len(s.entries), s.finished.Len())
+	}
+
+	// Освобожденный ключ дает место новому
+	s.Abort("fourth")
+	if _, err := s.Begin("sixth", "a"); err != nil {
+		t.Errorf("Begin() after Abort error = %v", err)
+	}
+}
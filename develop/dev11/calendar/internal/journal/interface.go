@@ -0,0 +1,10 @@
+package journal
+
+type IJournal interface {
+	Append(rec Record) error
+	AppendBatch(recs []Record) error
+	Replay(apply func(rec Record) error) error
+	Reset() error
+	Len() int
+	Close() error
+}
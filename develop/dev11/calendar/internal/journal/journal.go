@@ -0,0 +1,220 @@
+package journal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"dev11/calendar/pkg/logger"
+)
+
+// Размер заголовка записи: длина полезной нагрузки и ее контрольная сумма
+const headerSize = 8
+
+// Журнал операций (write-ahead log).
+// Формат записи: [4 байта длины][4 байта CRC32][json записи или массива записей пакета].
+// Пакет записей занимает одну запись журнала, поэтому при обрыве отбрасывается целиком
+type journal struct {
+	file   *os.File
+	logger logger.ILogger
+	mtx    sync.Mutex
+	// Количество записей с момента последнего сброса
+	length int
+}
+
+// Конструктор журнала операций. Если файл не существует, то он создается
+func NewJournal(fileName string, logger logger.ILogger) (IJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, fmt.Errorf("making dir: %w", err)
+	}
+
+	// O_APPEND гарантирует дозапись в конец независимо от позиции чтения
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	return &journal{file: file, logger: logger}, nil
+}
+
+// Добавление записи в журнал. Возвращает управление только после сброса записи на диск
+func (j *journal) Append(rec Record) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding record: %w", err)
+	}
+
+	return j.write(payload, 1)
+}
+
+// Добавление пакета записей, которые применяются при восстановлении все вместе или не применяются вовсе.
+// Возвращает управление только после сброса пакета на диск
+func (j *journal) AppendBatch(recs []Record) error {
+	payload, err := json.Marshal(recs)
+	if err != nil {
+		return fmt.Errorf("encoding records: %w", err)
+	}
+
+	return j.write(payload, len(recs))
+}
+
+// Запись полезной нагрузки payload, содержащей count записей, с заголовком и сбросом на диск
+func (j *journal) write(payload []byte, count int) error {
+	// Запись формируется целиком, чтобы попасть в файл одним вызовом Write
+	frame := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[headerSize:], payload)
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if _, err := j.file.Write(frame); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("syncing journal: %w", err)
+	}
+
+	j.length += count
+
+	return nil
+}
+
+// Последовательное применение записей журнала функцией apply.
+// Оборванная последняя запись (например, после падения во время записи) отбрасывается,
+// а файл обрезается до последней целой записи
+func (j *journal) Replay(apply func(rec Record) error) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	info, err := j.file.Stat()
+	if err != nil {
+		return fmt.Errorf("getting journal size: %w", err)
+	}
+	size := info.Size()
+
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking journal: %w", err)
+	}
+
+	var (
+		// Смещение конца последней целой записи
+		offset int64
+		header = make([]byte, headerSize)
+	)
+
+	j.length = 0
+
+	for offset < size {
+		// Чтение заголовка
+		if _, err := io.ReadFull(j.file, header); err != nil {
+			return j.truncateTorn(offset, err)
+		}
+
+		payloadSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		checksum := binary.BigEndian.Uint32(header[4:8])
+
+		// Длина выходит за пределы файла - запись не была дописана
+		end := offset + headerSize + payloadSize
+		if end > size {
+			return j.truncateTorn(offset, io.ErrUnexpectedEOF)
+		}
+
+		payload := make([]byte, payloadSize)
+		if _, err := io.ReadFull(j.file, payload); err != nil {
+			return j.truncateTorn(offset, err)
+		}
+
+		// Несовпадение контрольной суммы у последней записи считается обрывом,
+		// а в середине журнала - повреждением, которое нельзя исправить отбрасыванием хвоста
+		if crc32.ChecksumIEEE(payload) != checksum {
+			if end == size {
+				return j.truncateTorn(offset, errors.New("checksum mismatch"))
+			}
+			return fmt.Errorf("journal corrupted at offset %d: checksum mismatch", offset)
+		}
+
+		recs, err := decodeRecords(payload)
+		if err != nil {
+			return fmt.Errorf("decoding record at offset %d: %w", offset, err)
+		}
+
+		for _, rec := range recs {
+			if err := apply(rec); err != nil {
+				return fmt.Errorf("applying record at offset %d: %w", offset, err)
+			}
+		}
+
+		offset = end
+		j.length += len(recs)
+	}
+
+	return nil
+}
+
+// Сброс журнала после того, как его записи попали в снимок хранилища
+func (j *journal) Reset() error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if err := j.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncating journal: %w", err)
+	}
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("syncing journal: %w", err)
+	}
+
+	j.length = 0
+
+	return nil
+}
+
+// Количество записей с момента последнего сброса
+func (j *journal) Len() int {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	return j.length
+}
+
+// Закрытие файла журнала
+func (j *journal) Close() error {
+	return j.file.Close()
+}
+
+// Обрезка файла до смещения offset, на котором обнаружена оборванная запись.
+// Вызывается под мьютексом
+func (j *journal) truncateTorn(offset int64, cause error) error {
+	j.logger.Warn("truncating torn journal record", "offset", offset, "err", cause)
+
+	if err := j.file.Truncate(offset); err != nil {
+		return fmt.Errorf("truncating torn record: %w", err)
+	}
+
+	return nil
+}
+
+// Разбор полезной нагрузки записи журнала: одной записи или массива записей пакета
+func decodeRecords(payload []byte) ([]Record, error) {
+	if len(payload) > 0 && payload[0] == '[' {
+		var recs []Record
+		err := json.Unmarshal(payload, &recs)
+		return recs, err
+	}
+
+	var rec Record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return nil, err
+	}
+
+	return []Record{rec}, nil
+}
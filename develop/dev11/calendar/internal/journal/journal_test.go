@@ -0,0 +1,140 @@
+package journal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dev11/calendar/internal/model"
+	"dev11/calendar/pkg/logger"
+)
+
+// Логгер, отбрасывающий записи
+var discardLogger = logger.New(io.Discard, logger.LevelDebug)
+
+// Чтение всех записей журнала из файла fileName
+func replayAll(t *testing.T, fileName string) []Record {
+	j, err := NewJournal(fileName, discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	defer j.Close()
+
+	records := []Record{}
+	err = j.Replay(func(rec Record) error {
+		records = append(records, rec)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replaying journal: %v", err)
+	}
+
+	return records
+}
+
+func Test_journal_replay(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "journal.log")
+
+	j, err := NewJournal(fileName, discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	for id := 1; id <= 3; id++ {
+		if err := j.Append(Record{Op: OpInsert, Event: model.Event{ID: id}}); err != nil {
+			t.Fatalf("appending record: %v", err)
+		}
+	}
+	j.Close()
+
+	records := replayAll(t, fileName)
+	if len(records) != 3 || records[2].Event.ID != 3 {
+		t.Errorf("expected 3 records, got: %v", records)
+	}
+}
+
+func Test_journal_torn_last_record(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "journal.log")
+
+	j, err := NewJournal(fileName, discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	j.Append(Record{Op: OpInsert, Event: model.Event{ID: 1}})
+	j.Append(Record{Op: OpInsert, Event: model.Event{ID: 2}})
+	j.Close()
+
+	// Имитация падения во время записи: отрезание хвоста последней записи
+	info, _ := os.Stat(fileName)
+	if err := os.Truncate(fileName, info.Size()-3); err != nil {
+		t.Fatalf("truncating file: %v", err)
+	}
+
+	records := replayAll(t, fileName)
+	if len(records) != 1 || records[0].Event.ID != 1 {
+		t.Errorf("expected only first record, got: %v", records)
+	}
+
+	// После обрезки в журнал снова можно дописывать
+	j, _ = NewJournal(fileName, discardLogger)
+	j.Replay(func(Record) error { return nil })
+	j.Append(Record{Op: OpInsert, Event: model.Event{ID: 3}})
+	j.Close()
+
+	records = replayAll(t, fileName)
+	if len(records) != 2 || records[1].Event.ID != 3 {
+		t.Errorf("expected records 1 and 3, got: %v", records)
+	}
+}
+
+func Test_journal_reset(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "journal.log")
+
+	j, err := NewJournal(fileName, discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	j.Append(Record{Op: OpInsert, Event: model.Event{ID: 1}})
+	if err := j.Reset(); err != nil {
+		t.Fatalf("resetting journal: %v", err)
+	}
+	if j.Len() != 0 {
+		t.Errorf("expected empty journal, got length %d", j.Len())
+	}
+	j.Close()
+
+	if records := replayAll(t, fileName); len(records) != 0 {
+		t.Errorf("expected no records, got: %v", records)
+	}
+}
+
+func Test_journal_torn_batch(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "journal.log")
+
+	j, err := NewJournal(fileName, discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	j.Append(Record{Op: OpInsert, Event: model.Event{ID: 1}})
+	if err := j.AppendBatch([]Record{{Op: OpInsert, Event: model.Event{ID: 2}}, {Op: OpInsert, Event: model.Event{ID: 3}}}); err != nil {
+		t.Fatalf("appending batch: %v", err)
+	}
+	if j.Len() != 3 {
+		t.Errorf("expected length 3, got %d", j.Len())
+	}
+	j.Close()
+
+	if records := replayAll(t, fileName); len(records) != 3 || records[2].Event.ID != 3 {
+		t.Errorf("expected 3 records, got: %v", records)
+	}
+
+	// Оборванный пакет отбрасывается целиком
+	info, _ := os.Stat(fileName)
+	if err := os.Truncate(fileName, info.Size()-3); err != nil {
+		t.Fatalf("truncating file: %v", err)
+	}
+
+	if records := replayAll(t, fileName); len(records) != 1 || records[0].Event.ID != 1 {
+		t.Errorf("expected only first record, got: %v", records)
+	}
+}
@@ -0,0 +1,21 @@
+package journal
+
+import "dev11/calendar/internal/model"
+
+// Тип операции над событием
+type Op string
+
+const (
+	OpInsert Op = "insert"
+	OpUpdate Op = "update"
+	OpRemove Op = "remove"
+	// Окончательное удаление события из корзины
+	OpPurge Op = "purge"
+)
+
+// Запись журнала. Хранит состояние события после операции,
+// поэтому повторное применение записи не меняет результат
+type Record struct {
+	Op    Op          `json:"op"`
+	Event model.Event `json:"event"`
+}
@@ -0,0 +1,32 @@
+package metrics
+
+// Метрики HTTP-запросов: количество по маршруту, методу и статусу и длительность по маршруту и методу
+type HTTPMetrics struct {
+	Requests *CounterVec
+	Duration *HistogramVec
+}
+
+// Регистрация метрик HTTP-запросов в реестре registry
+func NewHTTPMetrics(registry *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		Requests: registry.Counter("calendar_http_requests_total",
+			"Total number of HTTP requests by route, method and status code.", "route", "method", "status"),
+		Duration: registry.Histogram("calendar_http_request_duration_seconds",
+			"HTTP request latency in seconds by route and method.", DefaultBuckets, "route", "method"),
+	}
+}
+
+// Регистрация счетчика записей в хранилище по операции (save - снимок, put - отдельное событие,
+// put_all - пакет событий, delete - окончательное удаление события, delete_all - окончательное
+// удаление нескольких событий) и результату (success, failure)
+func NewStorageWrites(registry *Registry) *CounterVec {
+	return registry.Counter("calendar_storage_writes_total",
+		"Total number of storage writes by operation and result.", "op", "result")
+}
+
+// Регистрация показателя количества хранимых неудаленных событий, вычисляемого функцией count
+func RegisterEventCount(registry *Registry, count func() int) {
+	registry.GaugeFunc("calendar_events", "Number of stored non-deleted events.", func() float64 {
+		return float64(count())
+	})
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// Счетчик с метками
+type CounterVec struct {
+	desc
+
+	mtx    sync.Mutex
+	values map[string]*counterValue
+}
+
+// Значение счетчика для набора меток
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// Увеличение счетчика с метками labels на единицу
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Увеличение счетчика с метками labels на delta
+func (c *CounterVec) Add(delta float64, labels ...string) {
+	c.checkLabels(labels)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	key := labelKey(labels)
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labels: append([]string{}, labels...)}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+// Вывод счетчика
+func (c *CounterVec) write(buf *bytes.Buffer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.writeHeader(buf, "counter")
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		fmt.Fprintf(buf, "%s%s %s\n", c.name, c.formatLabels(value.labels), formatFloat(value.value))
+	}
+}
+
+// Показатель, значение которого вычисляется при выводе
+type gaugeFunc struct {
+	desc
+	value func() float64
+}
+
+// Вывод показателя
+func (g *gaugeFunc) write(buf *bytes.Buffer) {
+	g.writeHeader(buf, "gauge")
+	fmt.Fprintf(buf, "%s %s\n", g.name, formatFloat(g.value()))
+}
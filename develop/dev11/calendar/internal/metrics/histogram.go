@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Границы корзин по умолчанию для длительностей в секундах
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Гистограмма с метками
+type HistogramVec struct {
+	desc
+	buckets []float64
+
+	mtx    sync.Mutex
+	values map[string]*histogramValue
+}
+
+// Значения гистограммы для набора меток
+type histogramValue struct {
+	labels []string
+	// Количество наблюдений в каждой корзине (не накопленное)
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Добавление наблюдения v в гистограмму с метками labels
+func (h *HistogramVec) Observe(v float64, labels ...string) {
+	h.checkLabels(labels)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	key := labelKey(labels)
+	value, ok := h.values[key]
+	if !ok {
+		value = &histogramValue{
+			labels: append([]string{}, labels...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = value
+	}
+
+	// Первая корзина, верхняя граница которой не меньше v
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		value.counts[i]++
+	}
+	value.count++
+	value.sum += v
+}
+
+// Вывод гистограммы: накопленные корзины, сумма и количество наблюдений
+func (h *HistogramVec) write(buf *bytes.Buffer) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.writeHeader(buf, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		value := h.values[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += value.counts[i]
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", h.name, h.formatLabels(value.labels, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", h.name, h.formatLabels(value.labels, "le", formatFloat(math.Inf(1))), value.count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", h.name, h.formatLabels(value.labels), formatFloat(value.sum))
+		fmt.Fprintf(buf, "%s_count%s %d\n", h.name, h.formatLabels(value.labels), value.count)
+	}
+}
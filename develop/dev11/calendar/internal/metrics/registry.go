@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Метрика, выводящая себя в текстовом формате Prometheus
+type collector interface {
+	write(buf *bytes.Buffer)
+}
+
+// Реестр метрик
+type Registry struct {
+	mtx        sync.Mutex
+	collectors []collector
+}
+
+// Конструктор реестра метрик
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Регистрация счетчика name с метками labels
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{
+		desc:   desc{name: name, help: help, labels: labels},
+		values: map[string]*counterValue{},
+	}
+	r.register(counter)
+
+	return counter
+}
+
+// Регистрация гистограммы name с границами корзин buckets и метками labels
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	histogram := &HistogramVec{
+		desc:    desc{name: name, help: help, labels: labels},
+		buckets: sorted,
+		values:  map[string]*histogramValue{},
+	}
+	r.register(histogram)
+
+	return histogram
+}
+
+// Регистрация показателя name, значение которого вычисляется функцией value при каждом выводе
+func (r *Registry) GaugeFunc(name, help string, value func() float64) {
+	r.register(&gaugeFunc{
+		desc:  desc{name: name, help: help},
+		value: value,
+	})
+}
+
+// Вывод всех метрик в текстовом формате Prometheus
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mtx.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.mtx.Unlock()
+
+	var buf bytes.Buffer
+	for _, c := range collectors {
+		c.write(&buf)
+	}
+
+	return buf.WriteTo(w)
+}
+
+// Добавление метрики в реестр
+func (r *Registry) register(c collector) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// Описание метрики
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// Вывод заголовка метрики с типом metricType
+func (d desc) writeHeader(buf *bytes.Buffer, metricType string) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help)
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", d.name, help, d.name, metricType)
+}
+
+// Ключ набора значений меток
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// Проверка количества значений меток
+func (d desc) checkLabels(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+}
+
+// Вывод меток {name="value",...} с дополнительной парой extra (например, le гистограммы)
+func (d desc) formatLabels(values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+1)
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	for i, value := range values {
+		pairs = append(pairs, d.labels[i]+`="`+escaper.Replace(value)+`"`)
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+escaper.Replace(extra[1])+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Представление числа в текстовом формате
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// Отсортированные ключи мапы для стабильного вывода
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Registry_WriteTo(t *testing.T) {
+	registry := NewRegistry()
+
+	requests := registry.Counter("requests_total", "Total requests.", "route", "status")
+	requests.Inc("/b", "200")
+	requests.Inc("/a", "500")
+	requests.Add(2, "/a", "500")
+
+	latency := registry.Histogram("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(3, "/a")
+
+	registry.GaugeFunc("events", "Stored \"events\"\nnow.", func() float64 { return 42 })
+
+	quoted := registry.Counter("quoted_total", "Quoted.", "value")
+	quoted.Inc("a\"b\\c")
+
+	var buf bytes.Buffer
+	if _, err := registry.WriteTo(&buf); err != nil {
+		t.Fatalf("writing metrics: %v", err)
+	}
+
+	expected := `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/a",status="500"} 3
+requests_total{route="/b",status="200"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 3.55
+latency_seconds_count{route="/a"} 3
+# HELP events Stored "events"\nnow.
+# TYPE events gauge
+events 42
+# HELP quoted_total Quoted.
+# TYPE quoted_total counter
+quoted_total{value="a\"b\\c"} 1
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected exposition:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
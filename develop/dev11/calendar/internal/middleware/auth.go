@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dev11/calendar/pkg/api_helper"
+)
+
+// Ключ контекста с ID аутентифицированного пользователя
+type userIDKey struct{}
+
+// Метод промежуточного слоя для аутентификации запроса.
+// Пользователь определяется по токену "Authorization: Bearer <user_id>.<подпись>"
+// либо по паре заголовков X-User-ID и X-Signature. Подпись - HMAC-SHA256 от user_id
+// на секрете secret в hex, user_id - положительное число. Запросы без корректной подписи
+// отклоняются с 401
+func Auth(secret []byte) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := authenticate(r, secret)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="calendar"`)
+				api_helper.WriteProblem(w, api_helper.Problem{
+					Status: http.StatusUnauthorized,
+					Code:   "unauthorized",
+					Detail: err.Error(),
+				})
+				return
+			}
+
+			setRequestUser(r.Context(), userID)
+
+			ctx := context.WithValue(r.Context(), userIDKey{}, userID)
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Получение ID аутентифицированного пользователя из контекста запроса
+func UserID(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int)
+	return userID, ok
+}
+
+// Формирование токена пользователя userID для заголовка Authorization
+func SignToken(secret []byte, userID int) string {
+	id := strconv.Itoa(userID)
+	return id + "." + sign(secret, id)
+}
+
+// Определение пользователя по заголовкам запроса
+func authenticate(r *http.Request, secret []byte) (int, error) {
+	var id, signature string
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		id, signature, _ = strings.Cut(token, ".")
+	} else if r.Header.Get("X-User-ID") != "" {
+		id, signature = r.Header.Get("X-User-ID"), r.Header.Get("X-Signature")
+	} else {
+		return 0, errors.New("authentication required")
+	}
+
+	userID, err := strconv.Atoi(id)
+	if err != nil || userID <= 0 {
+		return 0, errors.New("invalid user id in credentials")
+	}
+
+	// Сравнение за постоянное время не раскрывает подпись по времени ответа
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, id))) {
+		return 0, errors.New("invalid signature")
+	}
+
+	return userID, nil
+}
+
+// Подпись строки id секретом secret
+func sign(secret []byte, id string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
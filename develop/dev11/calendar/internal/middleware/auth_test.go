@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func Test_Auth(t *testing.T) {
+	secret := []byte("secret")
+
+	// Обработчик, отвечающий ID аутентифицированного пользователя
+	handler := Auth(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := UserID(r.Context())
+		w.Write([]byte(strconv.Itoa(userID)))
+	}))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"no credentials", nil, http.StatusUnauthorized, ""},
+		{"bearer token", map[string]string{"Authorization": "Bearer " + SignToken(secret, 7)}, http.StatusOK, "7"},
+		{"signature headers", map[string]string{"X-User-ID": "7", "X-Signature": SignToken(secret, 7)[2:]}, http.StatusOK, "7"},
+		{"token signed with another secret", map[string]string{"Authorization": "Bearer " + SignToken([]byte("other"), 7)}, http.StatusUnauthorized, ""},
+		{"token of another user", map[string]string{"Authorization": "Bearer 8." + SignToken(secret, 7)[2:]}, http.StatusUnauthorized, ""},
+		{"token without signature", map[string]string{"Authorization": "Bearer 7"}, http.StatusUnauthorized, ""},
+		{"not a number", map[string]string{"Authorization": "Bearer seven." + sign(secret, "seven")}, http.StatusUnauthorized, ""},
+		{"zero user", map[string]string{"Authorization": "Bearer " + SignToken(secret, 0)}, http.StatusUnauthorized, ""},
+		{"negative user", map[string]string{"Authorization": "Bearer " + SignToken(secret, -1)}, http.StatusUnauthorized, ""},
+		{"basic scheme", map[string]string{"Authorization": "Basic " + SignToken(secret, 7)}, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusUnauthorized {
+				if rec.Body.String() != tt.body {
+					t.Errorf("user = %q, want %q", rec.Body, tt.body)
+				}
+				return
+			}
+
+			if rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"dev11/calendar/internal/idempotency"
+	"dev11/calendar/pkg/api_helper"
+)
+
+const (
+	// Заголовок с ключом идемпотентности запроса
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// Заголовок ответа, повторенного по ключу идемпотентности
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// Наибольшая длина ключа идемпотентности
+	maxIdempotencyKeyLength = 255
+)
+
+// Метод промежуточного слоя для идемпотентной обработки запросов POST, PUT и PATCH
+// с заголовком Idempotency-Key. Ключи хранятся отдельно для каждого пользователя, поэтому слой
+// подключается после аутентификации. Первый запрос с ключом выполняется, и ответ на него
+// сохраняется в store вместе с отпечатком запроса: методом, адресом, заголовком If-Match и телом.
+// Повтор с тем же отпечатком получает сохраненный ответ с заголовком Idempotent-Replayed
+// без повторного выполнения. Запрос с другим отпечатком отклоняется с 422, а повтор, пришедший
+// до окончания первого запроса, - с 409. Ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+// Если хранилище заполнено обрабатываемыми запросами, запрос отклоняется с 503 до их завершения
+func Idempotency(store idempotency.IStore) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			userID, ok := UserID(r.Context())
+			if key == "" || !ok || !idempotentMethod(r.Method) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			if !validIdempotencyKey(key) {
+				api_helper.WriteProblem(w, api_helper.Problem{
+					Status: http.StatusBadRequest,
+					Code:   "validation_failed",
+					Detail: IdempotencyKeyHeader + " should be 1 to 255 printable ASCII characters",
+					Fields: []api_helper.ProblemField{{
+						Field:   IdempotencyKeyHeader,
+						Message: "should be 1 to 255 printable ASCII characters",
+					}},
+				})
+				return
+			}
+
+			// Тело читается целиком для отпечатка и передается обработчику заново
+			body, err := api_helper.ReadBody(w, r)
+			if err != nil {
+				writeBodyProblem(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := strconv.Itoa(userID) + ":" + key
+			saved, err := store.Begin(storeKey, fingerprint(r, body))
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused):
+				api_helper.WriteProblem(w, api_helper.Problem{
+					Status: http.StatusUnprocessableEntity,
+					Code:   "idempotency_key_reused",
+					Detail: err.Error(),
+				})
+				return
+			case errors.Is(err, idempotency.ErrInProgress):
+				api_helper.WriteProblem(w, api_helper.Problem{
+					Status: http.StatusConflict,
+					Code:   "idempotency_key_in_progress",
+					Detail: err.Error(),
+				})
+				return
+			case errors.Is(err, idempotency.ErrStoreFull):
+				headers := http.Header{}
+				headers.Set("Retry-After", "1")
+				api_helper.WriteProblem(w, api_helper.Problem{
+					Status: http.StatusServiceUnavailable,
+					Code:   "idempotency_store_full",
+					Detail: err.Error(),
+				}, headers)
+				return
+			case saved != nil:
+				replay(w, saved)
+				return
+			}
+
+			// Ключ освобождается, если обработчик не дошел до ответа или ответил ошибкой сервера
+			capture := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+			finished := false
+			defer func() {
+				if !finished {
+					store.Abort(storeKey)
+				}
+			}()
+
+			handler.ServeHTTP(capture, r)
+
+			if capture.status < http.StatusInternalServerError {
+				header := w.Header().Clone()
+				header.Del(RequestIDHeader)
+				store.Finish(storeKey, idempotency.Response{Status: capture.status, Header: header, Body: capture.body.Bytes()})
+				finished = true
+			}
+		})
+	}
+}
+
+// Признак метода, запросы которого обрабатываются идемпотентно по ключу
+func idempotentMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// Проверка ключа идемпотентности: ограниченной длины, из видимых символов ASCII
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Отпечаток запроса r с телом body
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.URL.RequestURI(), r.Header.Get("If-Match")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Ответ на ошибку чтения тела запроса
+func writeBodyProblem(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		api_helper.WriteProblem(w, api_helper.Problem{
+			Status: http.StatusRequestEntityTooLarge,
+			Code:   "payload_too_large",
+			Detail: err.Error(),
+		})
+		return
+	}
+
+	api_helper.WriteProblem(w, api_helper.Problem{
+		Status: http.StatusBadRequest,
+		Code:   "validation_failed",
+		Detail: "body can't be read",
+		Fields: []api_helper.ProblemField{{Field: "body", Message: "can't be read"}},
+	})
+}
+
+// Повтор сохраненного ответа saved
+func replay(w http.ResponseWriter, saved *idempotency.Response) {
+	for key, values := range saved.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(saved.Status)
+	w.Write(saved.Body)
+}
+
+// Обертка ответа, запоминающая статус и тело ответа
+type responseCapture struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// Запись статуса ответа
+func (c *responseCapture) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.status = status
+		c.wroteHeader = true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+// Запись тела ответа
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.wroteHeader = true
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// Исходный ответ для http.ResponseController
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
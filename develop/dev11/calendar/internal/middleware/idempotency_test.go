@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/idempotency"
+)
+
+// Обработчик, считающий вызовы и отвечающий статусом status с номером вызова в теле
+type countingHandler struct {
+	calls  int
+	status int
+	// Канал, закрытия которого ждет обработчик перед ответом. Nil - ответ сразу
+	release chan struct{}
+	started chan struct{}
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	if h.release != nil {
+		close(h.started)
+		<-h.release
+	}
+
+	w.Header().Set("Location", "/api/v2/events/1")
+	w.WriteHeader(h.status)
+	fmt.Fprintf(w, `{"call":%d}`, h.calls)
+}
+
+// Цепочка аутентификации и идемпотентности вокруг handler со своим хранилищем ключей
+func idempotentChain(handler http.Handler, maxKeys int) http.Handler {
+	return Auth([]byte("secret"))(Idempotency(idempotency.NewStore(time.Hour, maxKeys))(handler))
+}
+
+// Запрос method к chain от пользователя userID с ключом key и телом body
+func serveIdempotent(chain http.Handler, userID int, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v2/events", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+SignToken([]byte("secret"), userID))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	rec := httptest.NewRecorder()
+	chain.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Idempotency_replay(t *testing.T) {
+	handler := &countingHandler{status: http.StatusCreated}
+	chain := idempotentChain(handler, 10)
+
+	first := serveIdempotent(chain, 1, http.MethodPost, "k", `{"description":"a"}`)
+	replayed := serveIdempotent(chain, 1, http.MethodPost, "k", `{"description":"a"}`)
+
+	// Повтор получает сохраненный ответ без повторного выполнения
+	if handler.calls != 1 {
+		t.Fatalf("handler called %d times, want once", handler.calls)
+	}
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != first.Body.String() ||
+		replayed.Header().Get("Location") != "/api/v2/events/1" {
+		t.Errorf("replay = %d %q Location %q, want the first response", replayed.Code, replayed.Body, replayed.Header().Get("Location"))
+	}
+	if first.Header().Get(IdempotentReplayedHeader) != "" || replayed.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("Idempotent-Replayed = %q then %q, want none then true",
+			first.Header().Get(IdempotentReplayedHeader), replayed.Header().Get(IdempotentReplayedHeader))
+	}
+
+	// Ключи разных пользователей независимы, запросы без ключа и GET выполняются всегда
+	serveIdempotent(chain, 2, http.MethodPost, "k", `{"description":"a"}`)
+	serveIdempotent(chain, 1, http.MethodPost, "", `{"description":"a"}`)
+	serveIdempotent(chain, 1, http.MethodGet, "k", "")
+	if handler.calls != 4 {
+		t.Errorf("handler called %d times, want 4", handler.calls)
+	}
+}
+
+func Test_Idempotency_keyReused(t *testing.T) {
+	handler := &countingHandler{status: http.StatusCreated}
+	chain := idempotentChain(handler, 10)
+
+	serveIdempotent(chain, 1, http.MethodPost, "k", `{"description":"a"}`)
+
+	// Тот же ключ с другим телом или методом - 422 без выполнения
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		rec := serveIdempotent(chain, 1, method, "k", `{"description":"b"}`)
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "idempotency_key_reused") {
+			t.Errorf("%s with reused key = %d %s, want 422", method, rec.Code, rec.Body)
+		}
+	}
+	if handler.calls != 1 {
+		t.Errorf("handler called %d times, want once", handler.calls)
+	}
+
+	// Некорректный ключ - 400
+	if rec := serveIdempotent(chain, 1, http.MethodPost, "key with spaces", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid key status = %d, want 400", rec.Code)
+	}
+}
+
+func Test_Idempotency_serverErrorAborts(t *testing.T) {
+	handler := &countingHandler{status: http.StatusInternalServerError}
+	chain := idempotentChain(handler, 10)
+
+	// Ответ 5xx не сохраняется: повтор выполняется заново
+	serveIdempotent(chain, 1, http.MethodPost, "k", `{}`)
+	handler.status = http.StatusCreated
+	rec := serveIdempotent(chain, 1, http.MethodPost, "k", `{}`)
+
+	if handler.calls != 2 || rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("retry after 5xx: %d calls, status %d, replayed %q", handler.calls, rec.Code, rec.Header().Get(IdempotentReplayedHeader))
+	}
+
+	// Ответы 4xx сохраняются
+	handler.status = http.StatusBadRequest
+	serveIdempotent(chain, 1, http.MethodPost, "bad", `{}`)
+	if rec := serveIdempotent(chain, 1, http.MethodPost, "bad", `{}`); rec.Code != http.StatusBadRequest || handler.calls != 3 {
+		t.Errorf("retry after 4xx: %d calls, status %d, want saved 400", handler.calls, rec.Code)
+	}
+}
+
+func Test_Idempotency_inProgress(t *testing.T) {
+	handler := &countingHandler{status: http.StatusCreated, release: make(chan struct{}), started: make(chan struct{})}
+	chain := idempotentChain(handler, 1)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serveIdempotent(chain, 1, http.MethodPost, "k", `{}`) }()
+	<-handler.started
+
+	// Повтор до окончания первого запроса - 409, новый ключ при заполненном хранилище - 503
+	if rec := serveIdempotent(chain, 1, http.MethodPost, "k", `{}`); rec.Code != http.StatusConflict {
+		t.Errorf("retry in progress status = %d, want 409", rec.Code)
+	}
+	rec := serveIdempotent(chain, 1, http.MethodPost, "other", `{}`)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("new key over full store: status %d, Retry-After %q, want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	close(handler.release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("first request status = %d, want 201", first.Code)
+	}
+
+	// Завершенный ключ вытесняется новым
+	handler.release = nil
+	if rec := serveIdempotent(chain, 1, http.MethodPost, "other", `{}`); rec.Code != http.StatusCreated {
+		t.Errorf("new key after finish status = %d, want 201", rec.Code)
+	}
+}
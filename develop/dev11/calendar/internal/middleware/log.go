@@ -1,17 +1,139 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
 	"time"
+
+	"dev11/calendar/pkg/logger"
 )
 
-// Метод промежуточного слоя для логирования запроса
-func Log(handler http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		handler.ServeHTTP(w, r)
-		latency := time.Since(start)
-		log.Printf("remote address: %s; method: %s; URL: %s; latency %s\n", r.RemoteAddr, r.Method, r.URL, latency)
-	})
+// Заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// Наибольшая длина идентификатора запроса, принимаемого от клиента
+const maxRequestIDLength = 128
+
+// Ключ контекста со сведениями о запросе
+type requestInfoKey struct{}
+
+// Сведения о запросе, которые дополняются вложенными промежуточными слоями
+type requestInfo struct {
+	userID        int
+	authenticated bool
+}
+
+// Метод промежуточного слоя для логирования запросов маршрута route.
+// Запросу назначается идентификатор: переданный клиентом в X-Request-ID или новый. Он возвращается
+// в заголовке ответа. После обработки пишется запись с маршрутом, статусом, размером ответа,
+// задержкой и пользователем, если запрос прошел аутентификацию
+func Log(log logger.ILogger, route string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			info := &requestInfo{}
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			handler.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+			fields := []any{
+				"request_id", id,
+				"route", route,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"status", recorder.status,
+				"bytes", recorder.bytes,
+				"latency", time.Since(start),
+			}
+			if info.authenticated {
+				fields = append(fields, "user_id", info.userID)
+			}
+
+			switch {
+			case recorder.status >= http.StatusInternalServerError:
+				log.Error("request", fields...)
+			case recorder.status >= http.StatusBadRequest:
+				log.Warn("request", fields...)
+			default:
+				log.Info("request", fields...)
+			}
+		})
+	}
+}
+
+// Отметка запроса аутентифицированным пользователем userID
+func setRequestUser(ctx context.Context, userID int) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID = userID
+		info.authenticated = true
+	}
+}
+
+// Проверка идентификатора запроса от клиента: непустой, ограниченной длины,
+// из латинских букв, цифр и символов "-", "_", "."
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Новый случайный идентификатор запроса
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Обертка ответа, запоминающая статус и количество записанных байт
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// Запись статуса ответа
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Запись тела ответа
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Отправка буферизованных данных клиенту, если исходный ответ это поддерживает
+func (rec *responseRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Исходный ответ для http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
 }
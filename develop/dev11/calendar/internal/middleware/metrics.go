@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"dev11/calendar/internal/metrics"
+)
+
+// Метод промежуточного слоя для учета запросов маршрута route в метриках m:
+// количество запросов по статусу и длительность обработки
+func Metrics(m *metrics.HTTPMetrics, route string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			handler.ServeHTTP(recorder, r)
+
+			method := methodLabel(r.Method)
+			m.Requests.Inc(route, method, strconv.Itoa(recorder.status))
+			m.Duration.Observe(time.Since(start).Seconds(), route, method)
+		})
+	}
+}
+
+// Методы, учитываемые в метриках под своим именем
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// Значение метки метода. Прочие методы учитываются как "other",
+// чтобы клиент не мог неограниченно увеличивать число рядов метрик
+func methodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "other"
+}
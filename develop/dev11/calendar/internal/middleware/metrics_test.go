@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev11/calendar/internal/metrics"
+)
+
+func Test_Metrics_methodLabel(t *testing.T) {
+	registry := metrics.NewRegistry()
+	handler := Metrics(metrics.NewHTTPMetrics(registry), "/events")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, method := range []string{http.MethodGet, http.MethodPatch, "BREW", "PROPFIND"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/events", nil))
+	}
+
+	var buf bytes.Buffer
+	if _, err := registry.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{`method="GET"`, `method="PATCH"`, `route="/events",method="other",status="200"} 2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"BREW", "PROPFIND"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected metrics not to contain method %q", unwanted)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import "fmt"
+
+// Вид ошибки предметной области. По виду ошибки выбирается код ответа HTTP
+type ErrorKind int
+
+const (
+	// Внутренняя ошибка сервиса
+	KindInternal ErrorKind = iota
+	// Некорректные параметры запроса
+	KindValidation
+	// Отсутствие события или повторения
+	KindNotFound
+	// Обращение к чужому событию
+	KindForbidden
+	// Конфликт с текущим состоянием события
+	KindConflict
+)
+
+// Код ошибки валидации
+const CodeValidation = "validation_failed"
+
+// Поле запроса, не прошедшее валидацию
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Типизированная ошибка предметной области: вид, машиночитаемый код и описание.
+// Ошибка валидации дополнительно перечисляет поля запроса, не прошедшие проверку.
+// Ошибки-образцы сравниваются через errors.Is, в том числе обернутые с подробностями через %w
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Конструктор ошибки вида kind с кодом code
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Конструктор ошибки валидации поля field с описанием message (без имени поля)
+func NewValidationError(field, message string) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Code:    CodeValidation,
+		Message: fmt.Sprintf("%s %s", field, message),
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// Ошибка отсутствия события (или его удаления)
+var ErrEventNotFound = NewError(KindNotFound, "event_not_found", "event not found")
+
+// Ошибка изменения события, принадлежащего другому пользователю
+var ErrForbidden = NewError(KindForbidden, "forbidden", "event belongs to another user")
+
+// Ошибка несовпадения ожидаемой версии события с текущей
+var ErrVersionConflict = NewError(KindConflict, "version_conflict", "event version conflict")
+
+// Ошибка восстановления отдельно измененного повторения удаленной серии
+var ErrSeriesDeleted = NewError(KindConflict, "series_deleted", "series of the occurrence is deleted")
+
+// Ошибка обращения к пользователю, не приглашенному на событие
+var ErrAttendeeNotFound = NewError(KindNotFound, "attendee_not_found", "attendee not found")
+
+// Ошибка изменения события, время которого занято у одного из участников
+var ErrScheduleConflict = NewError(KindConflict, "schedule_conflict", "schedule conflict")
+
+// Ошибка поиска свободного времени, которого нет в периоде
+var ErrFreeSlotNotFound = NewError(KindNotFound, "free_slot_not_found", "no free slot in the range")
+
+// Ошибка обращения к дате, не являющейся повторением серии
+var ErrOccurrenceNotFound = NewError(KindNotFound, "occurrence_not_found", "occurrence not found")
+
+// Ошибка обращения к повторению события, не являющегося серией
+var ErrNotRecurring = NewError(KindConflict, "event_not_recurring", "event is not recurring")
@@ -1,15 +1,201 @@
 package model
 
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
 const (
 	// Формат даты
 	DateLayout = "2006-01-02"
+	// Формат времени начала и окончания события
+	TimeLayout = time.RFC3339
+	// Наибольшее время напоминания до начала события в минутах (4 недели)
+	MaxReminderMinutes = 4 * 7 * 24 * 60
+	// Наибольшее количество участников события
+	MaxAttendees = 100
+)
+
+// Ответы участника на приглашение
+const (
+	RSVPNeedsAction = "needs-action"
+	RSVPAccepted    = "accepted"
+	RSVPDeclined    = "declined"
+	RSVPTentative   = "tentative"
 )
 
-// Структура события
+// Участник события и его ответ на приглашение
+type Attendee struct {
+	UserID int    `json:"user_id"`
+	Status string `json:"status"`
+}
+
+// Структура события.
+// Событие на весь день задается только датой Date, событие со временем - моментами Start и End.
+// Для события со временем Date заполняется датой начала в часовом поясе события.
+//
+// Повторяющееся событие (серия) имеет правило Recurrence, а его отдельные повторения
+// идентифицируются датой начала. Исключенные из серии повторения перечислены в ExDates.
+// Отдельно измененное повторение хранится самостоятельным событием со ссылкой на серию SeriesID
+// и датой повторения RecurrenceID; та же дата RecurrenceID проставляется повторениям при развертке серии.
+//
+// UID - глобальный идентификатор события в iCalendar. Задается у импортированных событий,
+// для остальных вычисляется по ID.
+//
+// Version увеличивается при каждом изменении события и служит для оптимистичной блокировки.
+//
+// Reminders - времена напоминаний в минутах до начала события (для серии - каждого повторения).
+//
+// RemoveDate - дата удаления события в корзину. RemovedWithSeries отмечает повторения, удаленные
+// вместе с серией: при восстановлении серии возвращаются только они.
+//
+// UserId - организатор события, который единственный может менять его. Приглашенные участники
+// Attendees видят событие в своих выборках и отвечают на приглашение
 type Event struct {
-	ID          int    `json:"id,omitempty"`
-	UserId      int    `json:"user_id"`
-	Date        string `json:"date"`
-	RemoveDate  string `json:"remove_date,omitempty"`
-	Description string `json:"description"`
+	ID                int         `json:"id,omitempty"`
+	UID               string      `json:"uid,omitempty"`
+	Version           int         `json:"version"`
+	UserId            int         `json:"user_id"`
+	Date              string      `json:"date"`
+	Start             string      `json:"start,omitempty"`
+	End               string      `json:"end,omitempty"`
+	TimeZone          string      `json:"timezone,omitempty"`
+	Recurrence        *Recurrence `json:"recurrence,omitempty"`
+	ExDates           []string    `json:"exdates,omitempty"`
+	SeriesID          int         `json:"series_id,omitempty"`
+	RecurrenceID      string      `json:"recurrence_id,omitempty"`
+	Reminders         []int       `json:"reminders,omitempty"`
+	Attendees         []Attendee  `json:"attendees,omitempty"`
+	RemoveDate        string      `json:"remove_date,omitempty"`
+	RemovedWithSeries bool        `json:"removed_with_series,omitempty"`
+	Description       string      `json:"description"`
+}
+
+// Признак события на весь день
+func (e Event) IsAllDay() bool {
+	return e.Start == ""
+}
+
+// Интервал [start, end) события.
+// Событие на весь день без часового пояса считается "плавающим" и отсчитывается в поясе loc
+func (e Event) Interval(loc *time.Location) (start, end time.Time, err error) {
+	if !e.IsAllDay() {
+		if start, err = time.Parse(TimeLayout, e.Start); err != nil {
+			return time.Time{}, time.Time{}, errInvalidTime("start")
+		}
+
+		if end, err = time.Parse(TimeLayout, e.End); err != nil {
+			return time.Time{}, time.Time{}, errInvalidTime("end")
+		}
+
+		if end.Before(start) {
+			return time.Time{}, time.Time{}, NewValidationError("end", "should not be before start")
+		}
+
+		return start, end, nil
+	}
+
+	if e.TimeZone != "" {
+		if loc, err = time.LoadLocation(e.TimeZone); err != nil {
+			return time.Time{}, time.Time{}, errInvalidTimeZone
+		}
+	}
+
+	if start, err = time.ParseInLocation(DateLayout, e.Date, loc); err != nil {
+		return time.Time{}, time.Time{}, NewValidationError("date", "should be in format 2006-01-02")
+	}
+
+	return start, start.AddDate(0, 0, 1), nil
+}
+
+// Глобальный идентификатор события в iCalendar
+func (e Event) GetUID() string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return fmt.Sprintf("event-%d@dev11-calendar", e.ID)
+}
+
+// Тег сущности события для заголовка ETag
+func (e Event) ETag() string {
+	return strconv.Quote(strconv.Itoa(e.Version))
+}
+
+// Участник события userID
+func (e Event) Attendee(userID int) (Attendee, bool) {
+	for _, attendee := range e.Attendees {
+		if attendee.UserID == userID {
+			return attendee, true
+		}
+	}
+	return Attendee{}, false
+}
+
+// Признак того, что событие видно пользователю userID - организатору или участнику
+func (e Event) VisibleTo(userID int) bool {
+	_, ok := e.Attendee(userID)
+	return e.UserId == userID || ok
+}
+
+// Признак того, что событие занимает время пользователя userID - организатора
+// или участника, не отклонившего приглашение
+func (e Event) BusyFor(userID int) bool {
+	attendee, ok := e.Attendee(userID)
+	return e.UserId == userID || (ok && attendee.Status != RSVPDeclined)
+}
+
+// Признак серии повторяющихся событий
+func (e Event) IsRecurring() bool {
+	return e.Recurrence != nil
+}
+
+// Часовой пояс, в котором отсчитываются повторения события.
+// Для события со временем без пояса используется смещение из его начала, для "плавающего" события - loc
+func (e Event) Location(loc *time.Location) (*time.Location, error) {
+	if e.TimeZone != "" {
+		return time.LoadLocation(e.TimeZone)
+	}
+
+	if !e.IsAllDay() {
+		start, err := time.Parse(TimeLayout, e.Start)
+		if err != nil {
+			return nil, errInvalidTime("start")
+		}
+		return start.Location(), nil
+	}
+
+	return loc, nil
+}
+
+// Заполнение даты события со временем по дате начала в часовом поясе события
+func (e *Event) FillDate() error {
+	if e.IsAllDay() {
+		return nil
+	}
+
+	start, err := time.Parse(TimeLayout, e.Start)
+	if err != nil {
+		return errInvalidTime("start")
+	}
+
+	if e.TimeZone != "" {
+		loc, err := time.LoadLocation(e.TimeZone)
+		if err != nil {
+			return errInvalidTimeZone
+		}
+		start = start.In(loc)
+	}
+
+	e.Date = start.Format(DateLayout)
+
+	return nil
+}
+
+// Ошибка некорректного часового пояса события
+var errInvalidTimeZone = NewValidationError("timezone", "should be an IANA timezone name")
+
+// Ошибка некорректного момента времени в поле field
+func errInvalidTime(field string) error {
+	return NewValidationError(field, "should be in RFC 3339 format")
 }
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Обозначения границ периода: квадратная скобка включает границу, круглая - исключает
+const (
+	BoundsInclusive = "[]"
+	BoundsExclusive = "()"
+	BoundsHalfOpen  = "[)"
+	BoundsLeftOpen  = "(]"
+)
+
+// Период времени с настраиваемым включением границ
+type Range struct {
+	From        time.Time
+	To          time.Time
+	ExcludeFrom bool
+	ExcludeTo   bool
+}
+
+// Конструктор периода по обозначению границ bounds
+func NewRange(from, to time.Time, bounds string) (Range, error) {
+	if len(bounds) != 2 || (bounds[0] != '[' && bounds[0] != '(') || (bounds[1] != ']' && bounds[1] != ')') {
+		return Range{}, NewValidationError("bounds", fmt.Sprintf("should be one of %s, %s, %s, %s",
+			BoundsInclusive, BoundsHalfOpen, BoundsLeftOpen, BoundsExclusive))
+	}
+
+	return Range{
+		From:        from,
+		To:          to,
+		ExcludeFrom: bounds[0] == '(',
+		ExcludeTo:   bounds[1] == ')',
+	}, nil
+}
+
+// Период целых дней с даты from по дату to с границами bounds. Граница относится ко всему дню:
+// "[" и "]" включают день from и to, "(" и ")" исключают его. Результат - полуоткрытый период
+// от полуночи первого включенного дня до полуночи после последнего
+func NewDayRange(from, to time.Time, bounds string) (Range, error) {
+	rng, err := NewRange(startOfDay(from), startOfDay(to), bounds)
+	if err != nil {
+		return Range{}, err
+	}
+
+	if rng.ExcludeFrom {
+		rng.From = rng.From.AddDate(0, 0, 1)
+	}
+	if !rng.ExcludeTo {
+		rng.To = rng.To.AddDate(0, 0, 1)
+	}
+
+	return Range{From: rng.From, To: rng.To, ExcludeTo: true}, nil
+}
+
+// Отсутствие в периоде хотя бы одного момента
+func (r Range) empty() bool {
+	return r.To.Before(r.From) || (r.To.Equal(r.From) && (r.ExcludeFrom || r.ExcludeTo))
+}
+
+// Попадание момента t в период
+func (r Range) Contains(t time.Time) bool {
+	if t.Before(r.From) || (r.ExcludeFrom && t.Equal(r.From)) {
+		return false
+	}
+
+	if t.After(r.To) || (r.ExcludeTo && t.Equal(r.To)) {
+		return false
+	}
+
+	return true
+}
+
+// Пересечение периода с интервалом [start, end).
+// Интервал нулевой длины проверяется как момент start
+func (r Range) Overlaps(start, end time.Time) bool {
+	if r.empty() {
+		return false
+	}
+
+	if !end.After(start) {
+		return r.Contains(start)
+	}
+
+	if !end.After(r.From) {
+		return false
+	}
+
+	if r.ExcludeTo {
+		return start.Before(r.To)
+	}
+
+	return !start.After(r.To)
+}
+
+// Интервал времени [Start, End)
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Период дня, в котором находится date
+func DayRange(date time.Time) Range {
+	from := startOfDay(date)
+	return Range{From: from, To: from.AddDate(0, 0, 1), ExcludeTo: true}
+}
+
+// Период ISO-недели (с понедельника), в которой находится date
+func WeekRange(date time.Time) Range {
+	// Смещение от понедельника: воскресенье в ISO-неделе последнее
+	offset := (int(date.Weekday()) + 6) % 7
+	from := startOfDay(date).AddDate(0, 0, -offset)
+	return Range{From: from, To: from.AddDate(0, 0, 7), ExcludeTo: true}
+}
+
+// Период месяца, в котором находится date
+func MonthRange(date time.Time) Range {
+	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return Range{From: from, To: from.AddDate(0, 1, 0), ExcludeTo: true}
+}
+
+// Начало дня, в котором находится date
+func startOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Момент value в формате RFC 3339
+func at(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func Test_NewRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		bounds                 string
+		excludeFrom, excludeTo bool
+		wantErr                bool
+	}{
+		{BoundsInclusive, false, false, false},
+		{BoundsHalfOpen, false, true, false},
+		{BoundsLeftOpen, true, false, false},
+		{BoundsExclusive, true, true, false},
+		{"", false, false, true},
+		{"[", false, false, true},
+		{"[[", false, false, true},
+		{"]]", false, false, true},
+		{"[] ", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bounds, func(t *testing.T) {
+			rng, err := NewRange(from, to, tt.bounds)
+			if tt.wantErr {
+				var validationErr *Error
+				if !errors.As(err, &validationErr) || validationErr.Kind != KindValidation {
+					t.Errorf("NewRange() error = %v, want validation error", err)
+				}
+				return
+			}
+
+			want := Range{From: from, To: to, ExcludeFrom: tt.excludeFrom, ExcludeTo: tt.excludeTo}
+			if err != nil || rng != want {
+				t.Errorf("NewRange() = %+v, %v, want %+v", rng, err, want)
+			}
+		})
+	}
+}
+
+func Test_Range_Contains(t *testing.T) {
+	from := at(t, "2024-01-01T10:00:00Z")
+	to := at(t, "2024-01-01T12:00:00Z")
+
+	// Моменты в порядке: до периода, начало, внутри, конец, после периода
+	moments := []time.Time{from.Add(-time.Nanosecond), from, from.Add(time.Hour), to, to.Add(time.Nanosecond)}
+
+	tests := []struct {
+		bounds string
+		want   []bool
+	}{
+		{BoundsInclusive, []bool{false, true, true, true, false}},
+		{BoundsHalfOpen, []bool{false, true, true, false, false}},
+		{BoundsLeftOpen, []bool{false, false, true, true, false}},
+		{BoundsExclusive, []bool{false, false, true, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bounds, func(t *testing.T) {
+			rng, err := NewRange(from, to, tt.bounds)
+			if err != nil {
+				t.Fatalf("NewRange() error = %v", err)
+			}
+
+			for i, moment := range moments {
+				if got := rng.Contains(moment); got != tt.want[i] {
+					t.Errorf("Contains(%s) = %v, want %v", moment.Format(time.RFC3339Nano), got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("other time zone", func(t *testing.T) {
+		rng, _ := NewRange(from, to, BoundsHalfOpen)
+		moscow := time.FixedZone("MSK", 3*60*60)
+
+		// Тот же момент в другом поясе: 13:00 MSK = 10:00 UTC, 15:00 MSK = 12:00 UTC
+		if !rng.Contains(time.Date(2024, 1, 1, 13, 0, 0, 0, moscow)) {
+			t.Error("Contains() rejects the start in another time zone")
+		}
+		if rng.Contains(time.Date(2024, 1, 1, 15, 0, 0, 0, moscow)) {
+			t.Error("Contains() accepts the excluded end in another time zone")
+		}
+	})
+}
+
+func Test_NewDayRange_timeZone(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	// Дни считаются в поясе дат: полночь по Москве - 21:00 UTC предыдущего дня
+	rng, err := NewDayRange(time.Date(2024, 1, 1, 15, 0, 0, 0, moscow), time.Date(2024, 1, 2, 0, 0, 0, 0, moscow), BoundsInclusive)
+	if err != nil {
+		t.Fatalf("NewDayRange() error = %v", err)
+	}
+
+	want := Range{From: at(t, "2023-12-31T21:00:00Z"), To: at(t, "2024-01-02T21:00:00Z"), ExcludeTo: true}
+	if !rng.From.Equal(want.From) || !rng.To.Equal(want.To) || rng.ExcludeFrom || !rng.ExcludeTo {
+		t.Errorf("NewDayRange() = %+v, want %+v", rng, want)
+	}
+	if rng.From.Location() != moscow {
+		t.Errorf("NewDayRange() location = %v, want %v", rng.From.Location(), moscow)
+	}
+}
+
+func Test_Range_Overlaps(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	// События на границах периода 2024-01-01 - 2024-01-03: start и end интервала
+	events := []struct {
+		name       string
+		start, end string
+	}{
+		{"timed on from day", "2024-01-01T10:00:00Z", "2024-01-01T11:00:00Z"},
+		{"all-day on from day", "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"},
+		{"timed inside", "2024-01-02T10:00:00Z", "2024-01-02T11:00:00Z"},
+		{"timed on to day", "2024-01-03T10:00:00Z", "2024-01-03T11:00:00Z"},
+		{"all-day on to day", "2024-01-03T00:00:00Z", "2024-01-04T00:00:00Z"},
+		{"instant at to day midnight", "2024-01-03T00:00:00Z", "2024-01-03T00:00:00Z"},
+		{"timed on day after", "2024-01-04T00:00:00Z", "2024-01-04T01:00:00Z"},
+		{"timed on day before crossing midnight", "2023-12-31T23:00:00Z", "2024-01-01T01:00:00Z"},
+	}
+
+	// Ожидаемое пересечение для событий в порядке events при каждом обозначении границ
+	tests := []struct {
+		bounds string
+		want   []bool
+	}{
+		{BoundsInclusive, []bool{true, true, true, true, true, true, false, true}},
+		{BoundsHalfOpen, []bool{true, true, true, false, false, false, false, true}},
+		{BoundsLeftOpen, []bool{false, false, true, true, true, true, false, false}},
+		{BoundsExclusive, []bool{false, false, true, false, false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bounds, func(t *testing.T) {
+			rng, err := NewDayRange(from, to, tt.bounds)
+			if err != nil {
+				t.Fatalf("NewDayRange() error = %v", err)
+			}
+
+			for i, event := range events {
+				if got := rng.Overlaps(at(t, event.start), at(t, event.end)); got != tt.want[i] {
+					t.Errorf("Overlaps() for %s = %v, want %v", event.name, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_Range_OverlapsEmpty(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Один день с исключенными границами не содержит ни одного дня
+	rng, err := NewDayRange(day, day, BoundsExclusive)
+	if err != nil {
+		t.Fatalf("NewDayRange() error = %v", err)
+	}
+
+	// Многодневное событие охватывает весь пустой период
+	if rng.Overlaps(at(t, "2023-12-31T00:00:00Z"), at(t, "2024-01-03T00:00:00Z")) {
+		t.Error("empty range overlaps a multi-day event")
+	}
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Частоты повторения
+const (
+	FreqDaily   = "daily"
+	FreqWeekly  = "weekly"
+	FreqMonthly = "monthly"
+	FreqYearly  = "yearly"
+)
+
+// Дни недели в обозначениях RRULE (BYDAY)
+var Weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Правило повторения события в духе RRULE (RFC 5545).
+// ByDay допускается только для еженедельных и ежемесячных повторений,
+// Count и Until взаимоисключающие. Until задается датой или моментом в RFC 3339 и включается в серию
+type Recurrence struct {
+	Freq     string   `json:"freq"`
+	Interval int      `json:"interval,omitempty"`
+	ByDay    []string `json:"by_day,omitempty"`
+	Count    int      `json:"count,omitempty"`
+	Until    string   `json:"until,omitempty"`
+}
+
+// Валидация правила повторения
+func (r Recurrence) Validate() error {
+	switch r.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+	default:
+		return NewValidationError("recurrence.freq", fmt.Sprintf("should be one of %s, %s, %s, %s", FreqDaily, FreqWeekly, FreqMonthly, FreqYearly))
+	}
+
+	if r.Interval < 0 {
+		return NewValidationError("recurrence.interval", "should not be negative")
+	}
+
+	if len(r.ByDay) > 0 && r.Freq != FreqWeekly && r.Freq != FreqMonthly {
+		return NewValidationError("recurrence.by_day", "is supported only for weekly and monthly freq")
+	}
+
+	for _, day := range r.ByDay {
+		if _, ok := Weekdays[day]; !ok {
+			return NewValidationError("recurrence.by_day", fmt.Sprintf("contains unknown day %q", day))
+		}
+	}
+
+	if r.Count < 0 {
+		return NewValidationError("recurrence.count", "should not be negative")
+	}
+
+	if r.Count > 0 && r.Until != "" {
+		return NewValidationError("recurrence.until", "should not be used together with count")
+	}
+
+	if r.Until != "" {
+		if _, err := r.UntilTime(time.UTC); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Шаг повторения. Нулевой интервал означает 1
+func (r Recurrence) Step() int {
+	if r.Interval == 0 {
+		return 1
+	}
+	return r.Interval
+}
+
+// Последний допустимый момент начала повторения.
+// Дата в Until включает весь день в поясе loc
+func (r Recurrence) UntilTime(loc *time.Location) (time.Time, error) {
+	if until, err := time.Parse(TimeLayout, r.Until); err == nil {
+		return until, nil
+	}
+
+	until, err := time.ParseInLocation(DateLayout, r.Until, loc)
+	if err != nil {
+		return time.Time{}, NewValidationError("recurrence.until", "should be a date or RFC 3339 time")
+	}
+
+	return until.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Документ OpenAPI с описанием всех маршрутов сервиса
+//
+//go:embed openapi.json
+var document []byte
+
+// Префикс ссылки на схему компонентов
+const schemaRefPrefix = "#/components/schemas/"
+
+// Схемы компонентов документа по именам
+var schemas = loadSchemas(document)
+
+// Документ OpenAPI в формате JSON
+func Document() []byte {
+	return document
+}
+
+// Валидация тела запроса body по схеме компонента name.
+// Возвращает ошибку с путем до первого поля, не соответствующего схеме
+func ValidateJSON(name string, body []byte) error {
+	s, ok := schemas[name]
+	if !ok {
+		return fmt.Errorf("unknown schema %q", name)
+	}
+
+	// Числа сохраняются в исходном виде, чтобы отличать целые от дробных
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return invalid("", "should be a valid JSON: %v", err)
+	}
+
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return invalid("", "must have only a single JSON value")
+	}
+
+	return s.validate("", value)
+}
+
+// Загрузка схем компонентов из документа. Некорректный документ - ошибка сборки сервиса
+func loadSchemas(document []byte) map[string]*schema {
+	var doc struct {
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(document, &doc); err != nil {
+		panic(fmt.Sprintf("openapi: can't parse document: %v", err))
+	}
+
+	return doc.Components.Schemas
+}
+
+// Схема, на которую указывает ссылка ref
+func resolve(ref string) (*schema, error) {
+	s, ok := schemas[strings.TrimPrefix(ref, schemaRefPrefix)]
+	if !strings.HasPrefix(ref, schemaRefPrefix) || !ok {
+		return nil, fmt.Errorf("unknown schema reference %q", ref)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Все ссылки $ref документа указывают на существующие элементы
+func TestDocument_References(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal(Document(), &doc); err != nil {
+		t.Fatalf("document is not a valid JSON: %v", err)
+	}
+
+	var walk func(node any)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				target := doc
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					object, _ := target.(map[string]any)
+					target = object[part]
+				}
+				if target == nil {
+					t.Errorf("reference %q does not resolve", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		schema string
+		body   string
+		err    string
+	}{
+		{"InsertEvent", `{"date":"2023-05-01","description":"a"}`, ""},
+		{"InsertEvent", `{"start":"2023-05-01T10:00:00Z","end":"2023-05-01T11:00:00+03:00","description":"a",` +
+			`"timezone":"Europe/Moscow","reminders":[0,40320],"recurrence":{"freq":"weekly","by_day":["MO"],"until":"2023-06-01"}}`, ""},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a","recurrence":null,"reminders":null}`, ""},
+		{"InsertEvent", `[]`, "body should be an object"},
+		{"InsertEvent", `{"date":"2023-05-01"}`, "description is required"},
+		{"InsertEvent", `{"date":"2023-05-01","description":""}`, "description should not be empty"},
+		{"InsertEvent", `{"description":"a","start":"2023-05-01T10:00:00Z"}`, "date is required, or end is required"},
+		{"InsertEvent", `{"date":"01.05.2023","description":"a"}`, "date should be in format 2006-01-02"},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a","timezone":"Mars/Base"}`, "timezone should be an IANA timezone name"},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a","reminders":[10,-1]}`, "reminders[1] should be at least 0"},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a","reminders":[1,2,3,4,5,6,7,8,9,10,11]}`, "reminders should contain at most 10 items"},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a","recurrence":{"freq":"hourly"}}`,
+			"recurrence.freq should be one of daily, weekly, monthly, yearly"},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a","recurrence":{"freq":"daily","until":"soon"}}`,
+			"recurrence.until should be in format 2006-01-02, or recurrence.until should be in RFC 3339 format"},
+		{"InsertEvent", `{"date":"2023-05-01","description":"a"} {}`, "body must have only a single JSON value"},
+		{"UpdateEvent", `{"id":0,"date":"2023-05-01","description":"a"}`, "id should be at least 1"},
+		{"UpdateEvent", `{"id":1.5,"date":"2023-05-01","description":"a"}`, "id should be an integer"},
+		{"UpdateEvent", `{"id":"1","date":"2023-05-01","description":"a"}`, "id should be an integer"},
+		{"UpdateEvent", `{"id":1,"version":-1,"date":"2023-05-01","description":"a"}`, "version should be at least 0"},
+		{"RemoveEvent", `{"version":1}`, "id is required"},
+		{"RemoveEvent", `{"id":1,"occurrence":"2023-05-01"}`, ""},
+		{"PatchEvent", `{"description":null,"start":null}`, ""},
+		{"PatchEvent", `{"description":""}`, "description should not be empty"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateJSON(tt.schema, []byte(tt.body))
+
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.err {
+			t.Errorf("ValidateJSON(%s, %s) error = %q, want %q", tt.schema, tt.body, got, tt.err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package openapi
+
+import (
+	"dev11/calendar/internal/model"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Схема значения - подмножество JSON Schema из OpenAPI 3.0, используемое в документе сервиса.
+// Помимо стандартных форматов date и date-time поддерживается формат timezone - имя пояса IANA
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Nullable   bool               `json:"nullable"`
+	Enum       []any              `json:"enum"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	MinItems   *int               `json:"minItems"`
+	MaxItems   *int               `json:"maxItems"`
+	Items      *schema            `json:"items"`
+	Properties map[string]*schema `json:"properties"`
+	Required   []string           `json:"required"`
+	AllOf      []*schema          `json:"allOf"`
+	AnyOf      []*schema          `json:"anyOf"`
+	OneOf      []*schema          `json:"oneOf"`
+}
+
+// Названия типов в сообщениях об ошибках
+var typeNames = map[string]string{
+	"object":  "an object",
+	"array":   "an array",
+	"string":  "a string",
+	"integer": "an integer",
+	"number":  "a number",
+	"boolean": "a boolean",
+}
+
+// Валидация значения value, находящегося по пути path (пустой путь - все тело)
+func (s *schema) validate(path string, value any) error {
+	if s.Ref != "" {
+		target, err := resolve(s.Ref)
+		if err != nil {
+			return err
+		}
+		return target.validate(path, value)
+	}
+
+	if value == nil && s.Nullable {
+		return nil
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		return invalid(path, "should be %s", typeNames[s.Type])
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		return invalid(path, "should be one of %s", strings.Join(values, ", "))
+	}
+
+	var err error
+	switch v := value.(type) {
+	case string:
+		err = s.validateString(path, v)
+	case json.Number:
+		err = s.validateNumber(path, v)
+	case []any:
+		err = s.validateArray(path, v)
+	case map[string]any:
+		err = s.validateObject(path, v)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.validateCombinations(path, value)
+}
+
+// Валидация строки: длина и формат
+func (s *schema) validateString(path, value string) error {
+	length := utf8.RuneCountInString(value)
+
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			return invalid(path, "should not be empty")
+		}
+		return invalid(path, "should be at least %d characters long", *s.MinLength)
+	}
+
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return invalid(path, "should be at most %d characters long", *s.MaxLength)
+	}
+
+	switch s.Format {
+	case "date":
+		if _, err := time.Parse(model.DateLayout, value); err != nil {
+			return invalid(path, "should be in format 2006-01-02")
+		}
+	case "date-time":
+		if _, err := time.Parse(model.TimeLayout, value); err != nil {
+			return invalid(path, "should be in RFC 3339 format")
+		}
+	case "timezone":
+		if _, err := time.LoadLocation(value); err != nil {
+			return invalid(path, "should be an IANA timezone name")
+		}
+	}
+
+	return nil
+}
+
+// Валидация числа: границы
+func (s *schema) validateNumber(path string, value json.Number) error {
+	number, err := value.Float64()
+	if err != nil {
+		return invalid(path, "should be a number")
+	}
+
+	if s.Minimum != nil && number < *s.Minimum {
+		return invalid(path, "should be at least %s", formatNumber(*s.Minimum))
+	}
+
+	if s.Maximum != nil && number > *s.Maximum {
+		return invalid(path, "should be at most %s", formatNumber(*s.Maximum))
+	}
+
+	return nil
+}
+
+// Валидация массива: количество и элементы
+func (s *schema) validateArray(path string, value []any) error {
+	if s.MinItems != nil && len(value) < *s.MinItems {
+		return invalid(path, "should contain at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(value) > *s.MaxItems {
+		return invalid(path, "should contain at most %d items", *s.MaxItems)
+	}
+
+	if s.Items == nil {
+		return nil
+	}
+
+	for i, item := range value {
+		if err := s.Items.validate(fmt.Sprintf("%s[%d]", name(path), i), item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Валидация объекта: обязательные и описанные поля. Поля проверяются в порядке имен,
+// поэтому при нескольких ошибках сообщается всегда об одной и той же
+func (s *schema) validateObject(path string, value map[string]any) error {
+	for _, key := range s.Required {
+		if _, ok := value[key]; !ok {
+			return invalid(join(path, key), "is required")
+		}
+	}
+
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, ok := value[key]
+		if !ok {
+			continue
+		}
+
+		if err := s.Properties[key].validate(join(path, key), property); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Валидация сочетаний схем allOf, anyOf и oneOf
+func (s *schema) validateCombinations(path string, value any) error {
+	for _, sub := range s.AllOf {
+		if err := sub.validate(path, value); err != nil {
+			return err
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		// Ошибка перечисляет поля всех неподошедших вариантов
+		combined := &model.Error{Kind: model.KindValidation, Code: model.CodeValidation}
+		messages := make([]string, 0, len(s.AnyOf))
+		for _, sub := range s.AnyOf {
+			err := sub.validate(path, value)
+			if err == nil {
+				return nil
+			}
+
+			messages = append(messages, err.Error())
+			if typed, ok := err.(*model.Error); ok {
+				combined.Fields = append(combined.Fields, typed.Fields...)
+			}
+		}
+		combined.Message = strings.Join(messages, ", or ")
+		return combined
+	}
+
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if sub.validate(path, value) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return invalid(path, "should match exactly one of %d schemas", len(s.OneOf))
+		}
+	}
+
+	return nil
+}
+
+// Совпадение значения value с одним из значений перечисления
+func (s *schema) inEnum(value any) bool {
+	for _, allowed := range s.Enum {
+		switch v := value.(type) {
+		case string:
+			if allowed == v {
+				return true
+			}
+		case json.Number:
+			number, err := v.Float64()
+			if n, ok := allowed.(float64); ok && err == nil && n == number {
+				return true
+			}
+		case bool:
+			if allowed == v {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Соответствие значения value типу typ
+func hasType(value any, typ string) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return typ == "object"
+	case []any:
+		return typ == "array"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	case json.Number:
+		if typ == "number" {
+			return true
+		}
+		_, err := v.Int64()
+		return typ == "integer" && err == nil
+	}
+
+	return false
+}
+
+// Ошибка валидации значения по пути path с описанием, заданным форматом format
+func invalid(path, format string, args ...any) error {
+	return model.NewValidationError(name(path), fmt.Sprintf(format, args...))
+}
+
+// Имя значения по пути path в сообщениях
+func name(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
+
+// Путь до поля key объекта по пути path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Число в сообщениях без лишних нулей
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
@@ -0,0 +1,291 @@
+package recurrence
+
+import (
+	"dev11/calendar/internal/model"
+	"sort"
+	"time"
+)
+
+// Ограничение числа шагов развертки, защищающее от бесконечных серий с редкими совпадениями
+const maxSteps = 100000
+
+// Развертка серии event в повторения, пересекающиеся с периодом rng.
+// Повторения идут в хронологическом порядке, исключенные даты пропускаются.
+// Count отсчитывается от начала серии с учетом исключенных дат, как в RFC 5545
+func Expand(event model.Event, rng model.Range) ([]model.Event, error) {
+	occurrences := []model.Event{}
+
+	err := walk(event, rng.From.Location(), rng.From, func(start, end time.Time, date string, excluded bool) bool {
+		if start.After(rng.To) {
+			return false
+		}
+
+		if !excluded && rng.Overlaps(start, end) {
+			occurrences = append(occurrences, occurrence(event, start, end, date))
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return occurrences, nil
+}
+
+// Проверка, что дата date является неисключенным повторением серии event
+func IsOccurrence(event model.Event, date string) (bool, error) {
+	loc, err := event.Location(time.UTC)
+	if err != nil {
+		return false, err
+	}
+
+	day, err := time.ParseInLocation(model.DateLayout, date, loc)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	err = walk(event, loc, day, func(start, _ time.Time, occDate string, excluded bool) bool {
+		if occDate == date {
+			found = !excluded
+			return false
+		}
+		return !start.After(day)
+	})
+
+	return found, err
+}
+
+// Обход повторений серии event по порядку. Обход прекращается, когда yield возвращает false,
+// серия заканчивается по Count или Until, или исчерпан лимит шагов.
+// Повторения, закончившиеся до момента from, могут быть пропущены без выдачи: серия без Count
+// обходится не с начала, а с близкого к from периода. Серия с Count обходится с начала,
+// чтобы верно отсчитать повторения
+func walk(event model.Event, floating *time.Location, from time.Time, yield func(start, end time.Time, date string, excluded bool) bool) error {
+	rule := *event.Recurrence
+
+	// Часовой пояс повторений и интервал первого повторения
+	loc, err := event.Location(floating)
+	if err != nil {
+		return err
+	}
+
+	start, end, err := event.Interval(loc)
+	if err != nil {
+		return err
+	}
+	start = start.In(loc)
+	duration := end.Sub(start)
+
+	// Граница серии по Until
+	var until time.Time
+	if rule.Until != "" {
+		if until, err = rule.UntilTime(loc); err != nil {
+			return err
+		}
+	}
+
+	// Исключенные даты
+	exdates := make(map[string]bool, len(event.ExDates))
+	for _, date := range event.ExDates {
+		exdates[date] = true
+	}
+
+	// Начало обхода с запасом на длительность повторения и смену смещения пояса
+	var after time.Time
+	if rule.Count == 0 && !from.IsZero() {
+		after = from.Add(-duration).AddDate(0, 0, -1)
+	}
+
+	count := 0
+	generate(start, rule, after, func(occStart time.Time) bool {
+		if !until.IsZero() && occStart.After(until) {
+			return false
+		}
+
+		count++
+		if rule.Count > 0 && count > rule.Count {
+			return false
+		}
+
+		// Событие на весь день длится календарный день, даже при переходе на летнее время
+		occEnd := occStart.Add(duration)
+		if event.IsAllDay() {
+			occEnd = occStart.AddDate(0, 0, 1)
+		}
+
+		date := occStart.Format(model.DateLayout)
+		return yield(occStart, occEnd, date, exdates[date])
+	})
+
+	return nil
+}
+
+// Генерация моментов начала повторений в хронологическом порядке.
+// Повторения вычисляются по местному времени пояса start, поэтому время суток сохраняется при смене смещения.
+// Периоды правила, целиком лежащие до момента after, пропускаются без подсчета шагов
+func generate(start time.Time, rule model.Recurrence, after time.Time, yield func(time.Time) bool) {
+	step := rule.Step()
+	steps := 0
+	first := skipPeriods(start, rule.Freq, step, after)
+
+	// Момент с датой year-month-day и временем суток начала серии
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	// Учет шага без выдачи кандидата. Возвращает false при исчерпании лимита
+	skip := func() bool {
+		steps++
+		return steps <= maxSteps
+	}
+
+	// Выдача очередного кандидата с учетом начала серии и лимита шагов
+	emit := func(t time.Time) bool {
+		if !skip() {
+			return false
+		}
+		if t.Before(start) {
+			return true
+		}
+		return yield(t)
+	}
+
+	switch rule.Freq {
+	case model.FreqDaily:
+		for k := first; ; k++ {
+			if !emit(at(start.Year(), start.Month(), start.Day()+k*step)) {
+				return
+			}
+		}
+
+	case model.FreqWeekly:
+		days := weekdayOffsets(rule.ByDay, start.Weekday())
+
+		// Понедельник недели начала серии
+		monday := start.Day() - mondayOffset(start.Weekday())
+		for k := first; ; k++ {
+			for _, offset := range days {
+				if !emit(at(start.Year(), start.Month(), monday+k*7*step+offset)) {
+					return
+				}
+			}
+		}
+
+	case model.FreqMonthly:
+		for k := first; ; k++ {
+			month := start.Month() + time.Month(k*step)
+
+			// Без BYDAY повторение в тот же день месяца; месяцы без такого дня пропускаются
+			if len(rule.ByDay) == 0 {
+				t := at(start.Year(), month, start.Day())
+				if t.Day() != start.Day() {
+					if !skip() {
+						return
+					}
+					continue
+				}
+				if !emit(t) {
+					return
+				}
+				continue
+			}
+
+			// С BYDAY - все подходящие дни недели месяца
+			days := weekdaySet(rule.ByDay)
+			first := at(start.Year(), month, 1)
+			for t := first; t.Month() == first.Month(); t = at(t.Year(), t.Month(), t.Day()+1) {
+				if !days[t.Weekday()] {
+					continue
+				}
+				if !emit(t) {
+					return
+				}
+			}
+		}
+
+	case model.FreqYearly:
+		for k := first; ; k++ {
+			t := at(start.Year()+k*step, start.Month(), start.Day())
+
+			// 29 февраля повторяется только в високосные годы
+			if t.Day() != start.Day() {
+				if !skip() {
+					return
+				}
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// Количество периодов правила с частотой freq и шагом step от начала серии start, которые целиком
+// лежат до момента after и могут быть пропущены. Один период оставляется про запас
+func skipPeriods(start time.Time, freq string, step int, after time.Time) int {
+	if !after.After(start) {
+		return 0
+	}
+
+	var periods int
+	switch freq {
+	case model.FreqDaily:
+		periods = int(after.Sub(start).Hours() / 24)
+	case model.FreqWeekly:
+		periods = int(after.Sub(start).Hours() / (24 * 7))
+	case model.FreqMonthly:
+		periods = (after.Year()-start.Year())*12 + int(after.Month()) - int(start.Month())
+	case model.FreqYearly:
+		periods = after.Year() - start.Year()
+	}
+
+	if skipped := periods/step - 1; skipped > 0 {
+		return skipped
+	}
+	return 0
+}
+
+// Повторение серии event с интервалом [start, end) и датой date
+func occurrence(event model.Event, start, end time.Time, date string) model.Event {
+	event.Date = date
+	event.RecurrenceID = date
+
+	if !event.IsAllDay() {
+		event.Start = start.Format(model.TimeLayout)
+		event.End = end.Format(model.TimeLayout)
+	}
+
+	return event
+}
+
+// Смещение дня недели от понедельника
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// Отсортированные смещения дней BYDAY от понедельника. Без BYDAY - день недели начала серии
+func weekdayOffsets(byDay []string, fallback time.Weekday) []int {
+	if len(byDay) == 0 {
+		return []int{mondayOffset(fallback)}
+	}
+
+	offsets := make([]int, 0, len(byDay))
+	for day := range weekdaySet(byDay) {
+		offsets = append(offsets, mondayOffset(day))
+	}
+	sort.Ints(offsets)
+
+	return offsets
+}
+
+// Множество дней недели BYDAY
+func weekdaySet(byDay []string) map[time.Weekday]bool {
+	days := make(map[time.Weekday]bool, len(byDay))
+	for _, day := range byDay {
+		days[model.Weekdays[day]] = true
+	}
+	return days
+}
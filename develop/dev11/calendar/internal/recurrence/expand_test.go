@@ -0,0 +1,170 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/model"
+)
+
+type testCase struct {
+	event model.Event
+	rng   model.Range
+	dates []string
+}
+
+func test(tc testCase, t *testing.T) {
+	occurrences, err := Expand(tc.event, tc.rng)
+	if err != nil {
+		t.Fatalf("expanding: %v", err)
+	}
+
+	dates := []string{}
+	for _, occurrence := range occurrences {
+		dates = append(dates, occurrence.RecurrenceID)
+	}
+
+	if !reflect.DeepEqual(dates, tc.dates) {
+		t.Errorf("expected dates: %v, got: %v", tc.dates, dates)
+	}
+}
+
+// Период с from по to включительно
+func dateRange(from, to string) model.Range {
+	fromTime, _ := time.Parse(model.DateLayout, from)
+	toTime, _ := time.Parse(model.DateLayout, to)
+	return model.Range{From: fromTime, To: toTime}
+}
+
+func Test_Expand_daily_interval_count(t *testing.T) {
+	test(testCase{
+		event: model.Event{
+			Date:       "2023-05-01",
+			Recurrence: &model.Recurrence{Freq: model.FreqDaily, Interval: 2, Count: 3},
+		},
+		rng:   dateRange("2023-04-01", "2023-06-01"),
+		dates: []string{"2023-05-01", "2023-05-03", "2023-05-05"},
+	}, t)
+}
+
+func Test_Expand_weekly_by_day_with_exdates(t *testing.T) {
+	test(testCase{
+		event: model.Event{
+			Date:       "2023-05-03", // среда
+			Recurrence: &model.Recurrence{Freq: model.FreqWeekly, ByDay: []string{"MO", "WE", "FR"}, Until: "2023-05-12"},
+			ExDates:    []string{"2023-05-08"},
+		},
+		rng:   dateRange("2023-05-01", "2023-05-31"),
+		dates: []string{"2023-05-03", "2023-05-05", "2023-05-10", "2023-05-12"},
+	}, t)
+}
+
+func Test_Expand_monthly_skips_short_months(t *testing.T) {
+	test(testCase{
+		event: model.Event{
+			Date:       "2023-01-31",
+			Recurrence: &model.Recurrence{Freq: model.FreqMonthly},
+		},
+		rng:   dateRange("2023-01-01", "2023-05-31"),
+		dates: []string{"2023-01-31", "2023-03-31", "2023-05-31"},
+	}, t)
+}
+
+func Test_Expand_yearly_leap_day(t *testing.T) {
+	test(testCase{
+		event: model.Event{
+			Date:       "2020-02-29",
+			Recurrence: &model.Recurrence{Freq: model.FreqYearly},
+		},
+		rng:   dateRange("2020-01-01", "2028-12-31"),
+		dates: []string{"2020-02-29", "2024-02-29", "2028-02-29"},
+	}, t)
+}
+
+func Test_Expand_timed_keeps_local_time_across_dst(t *testing.T) {
+	occurrences, err := Expand(model.Event{
+		Start:      "2023-03-25T09:00:00+01:00",
+		End:        "2023-03-25T09:30:00+01:00",
+		TimeZone:   "Europe/Berlin",
+		Recurrence: &model.Recurrence{Freq: model.FreqDaily, Count: 2},
+	}, dateRange("2023-03-01", "2023-03-31"))
+	if err != nil {
+		t.Fatalf("expanding: %v", err)
+	}
+
+	// 26 марта Германия переходит на летнее время, но встреча остается в 9:00 по местному времени
+	if len(occurrences) != 2 || occurrences[1].Start != "2023-03-26T09:00:00+02:00" {
+		t.Errorf("unexpected occurrences: %v", occurrences)
+	}
+}
+
+func Test_Expand_starts_near_range(t *testing.T) {
+	tests := []struct {
+		name string
+		testCase
+	}{
+		// Без пропуска развертка исчерпала бы лимит шагов задолго до периода
+		{"daily from long ago", testCase{
+			event: model.Event{Date: "1700-01-01", Recurrence: &model.Recurrence{Freq: model.FreqDaily}},
+			rng:   dateRange("2023-05-01", "2023-05-03"),
+			dates: []string{"2023-05-01", "2023-05-02", "2023-05-03"},
+		}},
+		{"daily interval keeps phase", testCase{
+			event: model.Event{Date: "1900-01-01", Recurrence: &model.Recurrence{Freq: model.FreqDaily, Interval: 3}},
+			rng:   dateRange("2023-05-01", "2023-05-07"),
+			dates: []string{"2023-05-01", "2023-05-04", "2023-05-07"},
+		}},
+		{"weekly by day", testCase{
+			event: model.Event{Date: "1900-01-01", Recurrence: &model.Recurrence{Freq: model.FreqWeekly, Interval: 2, ByDay: []string{"MO", "WE"}}},
+			rng:   dateRange("2023-05-01", "2023-05-14"),
+			dates: []string{"2023-05-08", "2023-05-10"},
+		}},
+		{"monthly", testCase{
+			event: model.Event{Date: "1800-01-31", Recurrence: &model.Recurrence{Freq: model.FreqMonthly}},
+			rng:   dateRange("2023-04-01", "2023-05-31"),
+			dates: []string{"2023-05-31"},
+		}},
+		{"yearly", testCase{
+			event: model.Event{Date: "1000-05-02", Recurrence: &model.Recurrence{Freq: model.FreqYearly}},
+			rng:   dateRange("2023-01-01", "2024-12-31"),
+			dates: []string{"2023-05-02", "2024-05-02"},
+		}},
+		// Многодневное повторение, начавшееся до периода, попадает в него
+		{"long occurrence overlapping range start", testCase{
+			event: model.Event{Date: "1900-01-01", Start: "1900-01-01T00:00:00Z", End: "1900-01-05T00:00:00Z",
+				Recurrence: &model.Recurrence{Freq: model.FreqWeekly}},
+			rng:   dateRange("2023-05-03", "2023-05-03"),
+			dates: []string{"2023-05-01"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test(tt.testCase, t)
+		})
+	}
+}
+
+func Test_IsOccurrence(t *testing.T) {
+	event := model.Event{
+		Date:       "2023-05-01",
+		Recurrence: &model.Recurrence{Freq: model.FreqWeekly},
+		ExDates:    []string{"2023-05-15"},
+	}
+
+	for date, expected := range map[string]bool{
+		"2023-05-08": true,
+		"2023-05-09": false,
+		"2023-05-15": false,
+		"2023-04-24": false,
+	} {
+		ok, err := IsOccurrence(event, date)
+		if err != nil {
+			t.Fatalf("checking occurrence: %v", err)
+		}
+		if ok != expected {
+			t.Errorf("date %s: expected %v, got %v", date, expected, ok)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package reminder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Отправленные напоминания, сохраняемые в файл.
+// Для каждого напоминания хранится окончание события: после него напоминание
+// больше не может сработать, и запись о нем удаляется
+type firedStore struct {
+	fileName string
+	fired    map[string]time.Time
+}
+
+// Загрузка отправленных напоминаний из файла fileName. Отсутствующий файл означает пустой список
+func loadFiredStore(fileName string) (*firedStore, error) {
+	store := &firedStore{
+		fileName: fileName,
+		fired:    map[string]time.Time{},
+	}
+
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &store.fired); err != nil {
+		return nil, fmt.Errorf("decoding file: %w", err)
+	}
+
+	return store, nil
+}
+
+// Признак отправленного напоминания
+func (s *firedStore) has(key string) bool {
+	_, ok := s.fired[key]
+	return ok
+}
+
+// Отметка напоминания отправленным до окончания события end
+func (s *firedStore) add(key string, end time.Time) {
+	s.fired[key] = end
+}
+
+// Удаление записей о напоминаниях событий, закончившихся до now. Возвращает признак удаления
+func (s *firedStore) prune(now time.Time) bool {
+	pruned := false
+	for key, end := range s.fired {
+		if end.Before(now) {
+			delete(s.fired, key)
+			pruned = true
+		}
+	}
+
+	return pruned
+}
+
+// Сохранение отправленных напоминаний: запись во временный файл и атомарная замена основного
+func (s *firedStore) save() error {
+	dir := filepath.Dir(s.fileName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("making dir: %w", err)
+	}
+
+	data, err := json.Marshal(s.fired)
+	if err != nil {
+		return fmt.Errorf("encoding fired reminders: %w", err)
+	}
+
+	file, err := os.CreateTemp(dir, filepath.Base(s.fileName)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpName := file.Name()
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("syncing temp file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, s.fileName); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+
+	return nil
+}
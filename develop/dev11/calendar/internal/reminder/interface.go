@@ -0,0 +1,12 @@
+package reminder
+
+import "context"
+
+// Получатель напоминаний
+type INotifier interface {
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+type IScheduler interface {
+	Run(ctx context.Context)
+}
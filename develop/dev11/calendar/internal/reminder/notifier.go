@@ -0,0 +1,95 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// Вывод напоминаний строками JSON в поток
+	NotifierLog = "log"
+	// Отправка напоминаний POST-запросом на адрес вебхука
+	NotifierWebhook = "webhook"
+)
+
+// Таймаут запроса к вебхуку
+const webhookTimeout = 10 * time.Second
+
+// Создание получателя напоминаний по его типу notifierType.
+// Вебхук требует адреса url, лог пишется в w
+func NewNotifier(notifierType, url string, w io.Writer) (INotifier, error) {
+	switch notifierType {
+	case NotifierLog:
+		return NewLogNotifier(w), nil
+	case NotifierWebhook:
+		if url == "" {
+			return nil, fmt.Errorf("webhook notifier requires url")
+		}
+		return NewWebhookNotifier(url), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", notifierType)
+	}
+}
+
+// Получатель, выводящий напоминания в поток
+type logNotifier struct {
+	encoder *json.Encoder
+}
+
+// Конструктор получателя, выводящего напоминания строками JSON в w
+func NewLogNotifier(w io.Writer) INotifier {
+	return &logNotifier{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Вывод напоминания
+func (n *logNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	return n.encoder.Encode(reminder)
+}
+
+// Получатель, отправляющий напоминания на вебхук
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// Конструктор получателя, отправляющего напоминания POST-запросом с телом JSON на адрес url
+func NewWebhookNotifier(url string) INotifier {
+	return &webhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Отправка напоминания. Ответ вне диапазона 2xx считается ошибкой доставки
+func (n *webhookNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return fmt.Errorf("encoding reminder: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
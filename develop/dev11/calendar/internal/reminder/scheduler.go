@@ -0,0 +1,140 @@
+package reminder
+
+import (
+	"context"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/repository"
+	"dev11/calendar/pkg/logger"
+	"fmt"
+	"time"
+)
+
+// Напоминание о событии (для серии - о ее повторении Occurrence)
+type Reminder struct {
+	EventID       int       `json:"event_id"`
+	UserID        int       `json:"user_id"`
+	Description   string    `json:"description"`
+	Occurrence    string    `json:"occurrence,omitempty"`
+	Start         time.Time `json:"start"`
+	MinutesBefore int       `json:"minutes_before"`
+	FireAt        time.Time `json:"fire_at"`
+}
+
+// Ключ напоминания. Перенос события меняет начало, поэтому о перенесенном событии напоминание придет снова
+func (r Reminder) key() string {
+	return fmt.Sprintf("%d/%d/%d", r.EventID, r.Start.Unix(), r.MinutesBefore)
+}
+
+// Планировщик напоминаний. Раз в interval просматривает незакончившиеся события, начинающиеся
+// в пределах наибольшего времени напоминания, и отправляет наступившие напоминания.
+// Напоминание, пропущенное во время остановки сервиса, отправляется при запуске, если событие
+// еще не закончилось. Отправленные напоминания сохраняются в файл после отправки, поэтому
+// после перезапуска не повторяются; при падении между отправкой и сохранением возможен повтор
+type scheduler struct {
+	repo     repository.IEventRepository
+	notifier INotifier
+	interval time.Duration
+	fired    *firedStore
+	logger   logger.ILogger
+}
+
+// Конструктор планировщика напоминаний. Отправленные напоминания хранятся в файле firedFileName
+func NewScheduler(repo repository.IEventRepository, notifier INotifier, interval time.Duration, firedFileName string, logger logger.ILogger) (IScheduler, error) {
+	fired, err := loadFiredStore(firedFileName)
+	if err != nil {
+		return nil, fmt.Errorf("can't load fired reminders: %v", err)
+	}
+
+	return &scheduler{
+		repo:     repo,
+		notifier: notifier,
+		interval: interval,
+		fired:    fired,
+		logger:   logger,
+	}, nil
+}
+
+// Цикл отправки напоминаний. Блокируется до отмены контекста ctx
+func (s *scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.scan(ctx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.scan(ctx, now)
+		}
+	}
+}
+
+// Отправка напоминаний, наступивших к моменту now
+func (s *scheduler) scan(ctx context.Context, now time.Time) {
+	lookahead := time.Duration(model.MaxReminderMinutes) * time.Minute
+	rng, err := model.NewRange(now, now.Add(lookahead), model.BoundsInclusive)
+	if err != nil {
+		s.logger.Error("error while making reminders range", "err", err)
+		return
+	}
+
+	events, err := s.repo.GetRange(rng)
+	if err != nil {
+		s.logger.Error("error while getting events for reminders", "err", err)
+		return
+	}
+
+	changed := false
+	for _, event := range events {
+		if len(event.Reminders) == 0 {
+			continue
+		}
+
+		start, end, err := event.Interval(now.Location())
+		if err != nil {
+			s.logger.Error("error while parsing event's interval", "event_id", event.ID, "err", err)
+			continue
+		}
+
+		for _, minutes := range event.Reminders {
+			reminder := Reminder{
+				EventID:       event.ID,
+				UserID:        event.UserId,
+				Description:   event.Description,
+				Occurrence:    event.RecurrenceID,
+				Start:         start,
+				MinutesBefore: minutes,
+				FireAt:        start.Add(-time.Duration(minutes) * time.Minute),
+			}
+
+			// Напоминание еще не наступило или уже отправлено
+			if reminder.FireAt.After(now) || s.fired.has(reminder.key()) {
+				continue
+			}
+
+			// Неотправленное напоминание повторяется при следующем просмотре
+			if err := s.notifier.Notify(ctx, reminder); err != nil {
+				s.logger.Error("error while sending reminder", "event_id", event.ID, "user_id", event.UserId, "err", err)
+				continue
+			}
+
+			s.logger.Info("reminder sent", "event_id", event.ID, "user_id", event.UserId, "minutes_before", minutes)
+			s.fired.add(reminder.key(), end)
+			changed = true
+		}
+	}
+
+	if s.fired.prune(now) {
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := s.fired.save(); err != nil {
+		s.logger.Error("error while saving fired reminders", "err", err)
+	}
+}
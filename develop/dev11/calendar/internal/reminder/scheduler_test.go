@@ -0,0 +1,147 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/repository"
+	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
+)
+
+// Логгер, отбрасывающий записи
+var discardLogger = logger.New(io.Discard, logger.LevelDebug)
+
+// Получатель, запоминающий напоминания. Пока fail установлен, доставка завершается ошибкой
+type recordingNotifier struct {
+	reminders []Reminder
+	fail      bool
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	if n.fail {
+		return errors.New("notifier is down")
+	}
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+// Репозиторий во временной директории dir с событиями events
+func newRepository(t *testing.T, dir string, events ...model.Event) repository.IEventRepository {
+	store, err := storage.New(storage.TypeFile, filepath.Join(dir, "data.json"))
+	if err != nil {
+		t.Fatalf("opening storage: %v", err)
+	}
+
+	eventJournal, err := journal.NewJournal(filepath.Join(dir, "journal.log"), discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	t.Cleanup(func() { eventJournal.Close() })
+
+	repo, err := repository.NewEventRepository(store, eventJournal, discardLogger)
+	if err != nil {
+		t.Fatalf("opening repository: %v", err)
+	}
+
+	for _, event := range events {
+		if _, err := repo.Insert(event, nil); err != nil {
+			t.Fatalf("inserting event: %v", err)
+		}
+	}
+
+	return repo
+}
+
+func Test_scheduler_scan(t *testing.T) {
+	dir := t.TempDir()
+	firedFile := filepath.Join(dir, "reminders.json")
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	repo := newRepository(t, dir,
+		model.Event{
+			UserId:      1,
+			Date:        "2023-05-01",
+			Start:       start.Format(model.TimeLayout),
+			End:         start.Add(time.Hour).Format(model.TimeLayout),
+			Reminders:   []int{15, 60},
+			Description: "meeting",
+		},
+		model.Event{UserId: 1, Date: "2023-05-01", Description: "no reminders"},
+	)
+
+	notifier := &recordingNotifier{}
+	s, err := NewScheduler(repo, notifier, time.Minute, firedFile, discardLogger)
+	if err != nil {
+		t.Fatalf("creating scheduler: %v", err)
+	}
+
+	// За 30 минут наступило только напоминание за час
+	s.(*scheduler).scan(context.Background(), start.Add(-30*time.Minute))
+	if len(notifier.reminders) != 1 || notifier.reminders[0].MinutesBefore != 60 {
+		t.Fatalf("expected the 60 minute reminder, got %+v", notifier.reminders)
+	}
+
+	// Ошибка доставки не отмечает напоминание отправленным
+	notifier.fail = true
+	s.(*scheduler).scan(context.Background(), start.Add(-10*time.Minute))
+	notifier.fail = false
+	if len(notifier.reminders) != 1 {
+		t.Fatalf("expected no new reminders while notifier is down, got %+v", notifier.reminders)
+	}
+
+	s.(*scheduler).scan(context.Background(), start.Add(-5*time.Minute))
+	if len(notifier.reminders) != 2 || notifier.reminders[1].MinutesBefore != 15 {
+		t.Fatalf("expected the retried 15 minute reminder, got %+v", notifier.reminders)
+	}
+
+	// После перезапуска отправленные напоминания не повторяются
+	restarted := &recordingNotifier{}
+	s, err = NewScheduler(repo, restarted, time.Minute, firedFile, discardLogger)
+	if err != nil {
+		t.Fatalf("creating scheduler: %v", err)
+	}
+	s.(*scheduler).scan(context.Background(), start.Add(-time.Minute))
+	if len(restarted.reminders) != 0 {
+		t.Fatalf("expected no reminders after restart, got %+v", restarted.reminders)
+	}
+}
+
+func Test_scheduler_scan_recurring(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	repo := newRepository(t, dir, model.Event{
+		UserId:      1,
+		Date:        "2023-05-01",
+		Start:       start.Format(model.TimeLayout),
+		End:         start.Add(15 * time.Minute).Format(model.TimeLayout),
+		Recurrence:  &model.Recurrence{Freq: model.FreqDaily},
+		Reminders:   []int{10},
+		Description: "standup",
+	})
+
+	notifier := &recordingNotifier{}
+	s, err := NewScheduler(repo, notifier, time.Minute, filepath.Join(dir, "reminders.json"), discardLogger)
+	if err != nil {
+		t.Fatalf("creating scheduler: %v", err)
+	}
+
+	// Каждое повторение получает свое напоминание
+	for day := 0; day < 3; day++ {
+		s.(*scheduler).scan(context.Background(), start.AddDate(0, 0, day).Add(-5*time.Minute))
+	}
+
+	if len(notifier.reminders) != 3 {
+		t.Fatalf("expected 3 reminders, got %+v", notifier.reminders)
+	}
+	if notifier.reminders[2].Occurrence != "2023-05-03" {
+		t.Errorf("expected occurrence 2023-05-03, got %q", notifier.reminders[2].Occurrence)
+	}
+}
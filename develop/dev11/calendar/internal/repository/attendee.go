@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+)
+
+// Замена участников неудаленного события id списком, который функция change строит по текущему
+// состоянию события. Функция вызывается под мьютексом, поэтому одновременные ответы участников
+// не теряются. Участники серии переносятся на ее отдельно измененные повторения.
+// Возвращает новое состояние события
+func (repo *eventRepository) UpdateAttendees(id int, change func(event model.Event) ([]model.Attendee, error)) (model.Event, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	// Поиск неудаленного события
+	event, ok := repo.events[id]
+	if !ok || event.RemoveDate != "" {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	attendees, err := change(event)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	b := repo.newBatch()
+	if event.IsRecurring() {
+		for _, override := range repo.overrides(id) {
+			override.Attendees = attendees
+			b.put(journal.OpUpdate, override)
+		}
+	}
+
+	event.Attendees = attendees
+	event = b.put(journal.OpUpdate, event)
+
+	if err := repo.commitBatch(b); err != nil {
+		return model.Event{}, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"fmt"
+	"time"
+)
+
+// Вид изменения в пакете
+type MutationKind int
+
+const (
+	MutationInsert MutationKind = iota
+	MutationUpdate
+	MutationRemove
+	MutationUpsert
+)
+
+// Изменение в пакете: вставка события Event, замена события ID событием Event, удаление события ID
+// или вставка события Event с заменой неудаленного события того же организатора с тем же UID.
+// Ненулевая Version - ожидаемая текущая версия изменяемого события
+type Mutation struct {
+	Kind    MutationKind
+	ID      int
+	Version int
+	Event   model.Event
+}
+
+// Результат изменения в пакете: ID и новая версия события либо ошибка изменения.
+// Inserted отмечает вставку нового события
+type MutationResult struct {
+	ID       int
+	Version  int
+	Inserted bool
+	Err      error
+}
+
+// Пакет изменений, накапливаемых под мьютексом поверх текущих событий.
+// Purged - ID событий, окончательно удаляемых пакетом, uids - UID вставленных пакетом событий
+type batch struct {
+	repo    *eventRepository
+	staged  map[int]model.Event
+	purged  []int
+	uids    map[uidKey]int
+	records []journal.Record
+	counter int
+}
+
+// Применение пакета изменений mutations под одной блокировкой с одной записью в журнал и в хранилище.
+// В атомарном режиме ошибка любого изменения отменяет весь пакет, иначе применяются изменения
+// без ошибок. Ошибки изменений возвращаются в результатах, а ошибка записи в журнал - отдельно
+func (repo *eventRepository) Apply(mutations []Mutation, atomic bool) ([]MutationResult, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	b := repo.newBatch()
+	results := make([]MutationResult, len(mutations))
+	failed := false
+
+	for i, mutation := range mutations {
+		event, err := b.apply(mutation)
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+
+		// Первая версия бывает только у вставленного события
+		results[i].ID, results[i].Version, results[i].Inserted = event.ID, event.Version, event.Version == 1
+	}
+
+	if atomic && failed {
+		return results, nil
+	}
+
+	if err := repo.commitBatch(b); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Новый пакет изменений. Вызывается под мьютексом
+func (repo *eventRepository) newBatch() *batch {
+	return &batch{repo: repo, staged: map[int]model.Event{}, uids: map[uidKey]int{}, counter: repo.counter}
+}
+
+// Применение изменения mutation к пакету. Возвращает новое состояние события.
+// Изменение проверяется до того, как попасть в пакет, поэтому ошибка не оставляет следов в пакете
+func (b *batch) apply(mutation Mutation) (model.Event, error) {
+	switch mutation.Kind {
+	case MutationInsert:
+		return b.insert(mutation.Event), nil
+	case MutationUpsert:
+		return b.upsert(mutation.Event), nil
+	}
+
+	// Поиск неудаленного события
+	current, ok := b.get(mutation.ID)
+	if !ok || current.RemoveDate != "" {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	if err := checkVersion(current, mutation.Version); err != nil {
+		return model.Event{}, err
+	}
+
+	switch mutation.Kind {
+	case MutationUpdate:
+		updated, err := replaced(current, mutation.Event)
+		if err != nil {
+			return model.Event{}, err
+		}
+		return b.put(journal.OpUpdate, updated), nil
+
+	case MutationRemove:
+		// Вместе с серией удаляются ее отдельно измененные повторения
+		removeDate := time.Now().Format(model.DateLayout)
+		if current.IsRecurring() {
+			for _, override := range b.repo.overrides(current.ID) {
+				if override, _ = b.get(override.ID); override.RemoveDate == "" {
+					override.RemoveDate = removeDate
+					override.RemovedWithSeries = true
+					b.put(journal.OpRemove, override)
+				}
+			}
+		}
+
+		current.RemoveDate = removeDate
+		return b.put(journal.OpRemove, current), nil
+	}
+
+	return model.Event{}, fmt.Errorf("unknown mutation kind %d", mutation.Kind)
+}
+
+// Вставка в пакет нового события event
+func (b *batch) insert(event model.Event) model.Event {
+	event.ID = b.counter
+	b.counter++
+	b.uids[uidKey{userID: event.UserId, uid: event.GetUID()}] = event.ID
+
+	return b.put(journal.OpInsert, event)
+}
+
+// Вставка в пакет события event или замена неудаленного события того же организатора с тем же UID,
+// в том числе вставленного этим же пакетом. Участники и привязка к серии заменяемого события сохраняются
+func (b *batch) upsert(event model.Event) model.Event {
+	id, ok := b.uids[uidKey{userID: event.UserId, uid: event.UID}]
+	if !ok {
+		id, ok = b.repo.index.findUID(event.UserId, event.UID)
+	}
+
+	existing, found := b.get(id)
+	if !ok || !found || existing.RemoveDate != "" {
+		return b.insert(event)
+	}
+
+	event.ID = existing.ID
+	event.Attendees = existing.Attendees
+	event.SeriesID = existing.SeriesID
+	event.RecurrenceID = existing.RecurrenceID
+
+	return b.put(journal.OpUpdate, event)
+}
+
+// Событие id с учетом изменений пакета
+func (b *batch) get(id int) (model.Event, bool) {
+	if event, ok := b.staged[id]; ok {
+		return event, true
+	}
+
+	event, ok := b.repo.events[id]
+	return event, ok
+}
+
+// Добавление в пакет операции op над событием event. Версия события увеличивается на единицу
+func (b *batch) put(op journal.Op, event model.Event) model.Event {
+	current, _ := b.get(event.ID)
+	event.Version = current.Version + 1
+
+	b.staged[event.ID] = event
+	b.records = append(b.records, journal.Record{Op: op, Event: event})
+
+	return event
+}
+
+// Добавление в пакет окончательного удаления события id
+func (b *batch) purge(id int) {
+	delete(b.staged, id)
+	b.purged = append(b.purged, id)
+	b.records = append(b.records, journal.Record{Op: journal.OpPurge, Event: model.Event{ID: id}})
+}
+
+// Фиксация пакета b: одна запись в журнал, по одной записи и одному удалению в хранилище
+// и применение к локальной мапе. Вызывается под мьютексом
+func (repo *eventRepository) commitBatch(b *batch) error {
+	if len(b.records) == 0 {
+		return nil
+	}
+
+	// Запись в журнал. Без нее пакет не подтверждается
+	if err := repo.journal.AppendBatch(b.records); err != nil {
+		return fmt.Errorf("can't append to journal: %v", err)
+	}
+
+	events := make([]model.Event, 0, len(b.staged))
+	for _, event := range b.staged {
+		events = append(events, event)
+	}
+
+	// Пакет уже сохранен в журнале и попадет в следующий снимок,
+	// поэтому ошибка записи в хранилище его не отменяет
+	if len(events) > 0 {
+		if err := repo.storage.PutAll(events); err != nil {
+			repo.logger.Error("error while putting events to storage", "count", len(events), "err", err)
+		}
+	}
+	if len(b.purged) > 0 {
+		if err := repo.storage.DeleteAll(b.purged); err != nil {
+			repo.logger.Error("error while deleting events from storage", "count", len(b.purged), "err", err)
+		}
+	}
+
+	for _, event := range events {
+		repo.events[event.ID] = event
+		repo.index.remove(event.ID)
+		repo.indexEvent(event)
+	}
+	for _, id := range b.purged {
+		delete(repo.events, id)
+		repo.index.remove(id)
+	}
+	repo.counter = b.counter
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+)
+
+func Test_eventRepository_Apply(t *testing.T) {
+	dir := t.TempDir()
+	repo := openRepository(t, dir)
+
+	id, err := repo.Insert(model.Event{UserId: 1, Date: "2023-05-01", Description: "a"}, nil)
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+
+	mutations := []Mutation{
+		{Kind: MutationInsert, Event: model.Event{UserId: 1, Date: "2023-05-02", Description: "b"}},
+		{Kind: MutationUpdate, ID: id, Version: 1, Event: model.Event{UserId: 1, Date: "2023-05-03", Description: "a2"}},
+		{Kind: MutationRemove, ID: id, Version: 5},
+	}
+
+	// Атомарный пакет с конфликтом версий не применяется вовсе
+	results, err := repo.Apply(mutations, true)
+	if err != nil {
+		t.Fatalf("applying atomic batch: %v", err)
+	}
+	if !errors.Is(results[2].Err, model.ErrVersionConflict) {
+		t.Errorf("expected version conflict, got %v", results[2].Err)
+	}
+	if event, _ := repo.GetByID(id); event.Version != 1 || repo.Count() != 1 {
+		t.Errorf("expected atomic batch to be discarded, got event %+v and %d events", event, repo.Count())
+	}
+
+	// Пакет без атомарности применяет операции без ошибок, в том числе зависящие от предыдущих
+	mutations[2].Version = 2
+	mutations = append(mutations, Mutation{Kind: MutationRemove, ID: 100})
+	results, err = repo.Apply(mutations, false)
+	if err != nil {
+		t.Fatalf("applying batch: %v", err)
+	}
+	for i, result := range results[:3] {
+		if result.Err != nil {
+			t.Errorf("operation %d: unexpected error %v", i, result.Err)
+		}
+	}
+	if !errors.Is(results[3].Err, model.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for a missing event, got %v", results[3].Err)
+	}
+	if results[2].Version != 3 {
+		t.Errorf("expected version 3 after update and removal, got %d", results[2].Version)
+	}
+
+	// Пакет попадает в журнал одной записью и переживает перезапуск
+	if repo.PendingChanges() != 4 {
+		t.Errorf("expected 4 journal records, got %d", repo.PendingChanges())
+	}
+	repo = openRepository(t, dir)
+	if _, err := repo.GetByID(id); !errors.Is(err, model.ErrEventNotFound) {
+		t.Errorf("expected removed event after restart, got %v", err)
+	}
+	if event, err := repo.GetByID(results[0].ID); err != nil || event.Description != "b" {
+		t.Errorf("expected inserted event after restart, got %+v, %v", event, err)
+	}
+
+	// Новые события получают ID после вставленных пакетом
+	if next, _ := repo.Insert(model.Event{UserId: 1, Date: "2023-05-04", Description: "c"}, nil); next != results[0].ID+1 {
+		t.Errorf("expected next ID %d, got %d", results[0].ID+1, next)
+	}
+}
+
+// Журнал, считающий записи и по требованию отказывающий в записи пакета
+type countingJournal struct {
+	journal.IJournal
+	appends, batches int
+	failBatch        bool
+}
+
+func (j *countingJournal) Append(rec journal.Record) error {
+	j.appends++
+	return j.IJournal.Append(rec)
+}
+
+func (j *countingJournal) AppendBatch(recs []journal.Record) error {
+	if j.failBatch {
+		return errors.New("disk full")
+	}
+	j.batches++
+	return j.IJournal.AppendBatch(recs)
+}
+
+func Test_eventRepository_seriesChangesAreBatched(t *testing.T) {
+	repo := openRepository(t, t.TempDir()).(*eventRepository)
+
+	seriesID, err := repo.Insert(model.Event{
+		UserId: 1, Date: "2023-05-01", Recurrence: &model.Recurrence{Freq: model.FreqDaily}, Description: "s",
+	}, nil)
+	if err != nil {
+		t.Fatalf("inserting series: %v", err)
+	}
+
+	recorder := &countingJournal{IJournal: repo.journal}
+	repo.journal = recorder
+
+	// Ошибка записи пакета не оставляет ни исключенной даты, ни события повторения
+	recorder.failBatch = true
+	if _, err := repo.DetachOccurrence(seriesID, "2023-05-02", 0, model.Event{UserId: 1, Date: "2023-05-02", Description: "moved"}, nil); err == nil {
+		t.Fatal("expected journal error")
+	}
+	if series, _ := repo.GetByID(seriesID); len(series.ExDates) != 0 || repo.Count() != 1 {
+		t.Fatalf("expected failed detach to leave no trace, got series %+v and %d events", series, repo.Count())
+	}
+	recorder.failBatch = false
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"detach", func() error {
+			_, err := repo.DetachOccurrence(seriesID, "2023-05-02", 0, model.Event{UserId: 1, Date: "2023-05-02", Description: "moved"}, nil)
+			return err
+		}},
+		{"remove", func() error { return repo.Remove(seriesID, 0) }},
+		{"restore", func() error { return repo.Restore(seriesID) }},
+	}
+
+	// Каждое изменение серии вместе с повторением попадает в журнал одной записью пакета
+	for i, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if recorder.appends != 0 || recorder.batches != i+1 {
+			t.Errorf("%s: expected one more batch, got %d appends and %d batches in total", step.name, recorder.appends, recorder.batches)
+		}
+	}
+
+	if repo.Count() != 2 {
+		t.Errorf("expected series and its override after restore, got %d events", repo.Count())
+	}
+}
+
+func Test_eventRepository_ApplyUpsert(t *testing.T) {
+	dir := t.TempDir()
+	repo := openRepository(t, dir)
+
+	existing, err := repo.Insert(model.Event{UID: "a", UserId: 1, Date: "2023-05-01", Description: "a",
+		Attendees: []model.Attendee{{UserID: 2, Status: model.RSVPAccepted}}}, nil)
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+
+	// Событие с известным UID заменяется, повтор UID в пакете заменяет вставленное им же событие,
+	// а тот же UID другого организатора дает новое событие
+	results, err := repo.Apply([]Mutation{
+		{Kind: MutationUpsert, Event: model.Event{UID: "a", UserId: 1, Date: "2023-05-02", Description: "a2"}},
+		{Kind: MutationUpsert, Event: model.Event{UID: "b", UserId: 1, Date: "2023-05-03", Description: "b"}},
+		{Kind: MutationUpsert, Event: model.Event{UID: "b", UserId: 1, Date: "2023-05-04", Description: "b2"}},
+		{Kind: MutationUpsert, Event: model.Event{UID: "a", UserId: 2, Date: "2023-05-05", Description: "other"}},
+	}, true)
+	if err != nil {
+		t.Fatalf("applying upserts: %v", err)
+	}
+
+	inserted := []bool{}
+	for _, result := range results {
+		inserted = append(inserted, result.Inserted)
+	}
+	if want := []bool{false, true, false, true}; !reflect.DeepEqual(inserted, want) {
+		t.Errorf("inserted = %v, want %v", inserted, want)
+	}
+	if results[0].ID != existing || results[2].ID != results[1].ID || repo.Count() != 3 {
+		t.Errorf("results = %+v with %d events", results, repo.Count())
+	}
+	if event, _ := repo.GetByID(existing); event.Description != "a2" || len(event.Attendees) != 1 {
+		t.Errorf("replaced event = %+v, want new fields and kept attendees", event)
+	}
+
+	// Индекс UID восстанавливается после перезапуска и не находит удаленные события
+	repo = openRepository(t, dir)
+	if err := repo.Remove(existing, 0); err != nil {
+		t.Fatalf("removing event: %v", err)
+	}
+	results, err = repo.Apply([]Mutation{
+		{Kind: MutationUpsert, Event: model.Event{UID: "b", UserId: 1, Date: "2023-05-06", Description: "b3"}},
+		{Kind: MutationUpsert, Event: model.Event{UID: "a", UserId: 1, Date: "2023-05-07", Description: "a3"}},
+	}, true)
+	if err != nil {
+		t.Fatalf("applying upserts after restart: %v", err)
+	}
+	if results[0].Inserted || !results[1].Inserted || results[1].ID == existing {
+		t.Errorf("results after restart = %+v", results)
+	}
+}
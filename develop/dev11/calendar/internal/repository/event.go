@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"dev11/calendar/internal/journal"
 	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/recurrence"
 	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
 	"errors"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 )
@@ -13,22 +15,23 @@ import (
 // Репозиторий событий
 type eventRepository struct {
 	storage storage.IStorage
+	journal journal.IJournal
+	logger  logger.ILogger
 	events  map[int]model.Event
+	index   *eventIndex
 	mtx     sync.RWMutex
 	counter int
 }
 
-// Конструктор репозитория событий
-func NewEventRepository(storage storage.IStorage) (IEventRepository, error) {
+// Конструктор репозитория событий.
+// Состояние восстанавливается из снимка хранилища, поверх которого применяется журнал операций
+func NewEventRepository(storage storage.IStorage, eventJournal journal.IJournal, logger logger.ILogger) (IEventRepository, error) {
 	// Получение событий
 	events, err := storage.Get()
 	if err != nil {
 		return nil, fmt.Errorf("can't get events: %v", err)
 	}
 
-	// Наибольший ID
-	var maxID int
-
 	// Мапа событий
 	eventsMap := make(map[int]model.Event, len(events))
 
@@ -39,61 +42,114 @@ func NewEventRepository(storage storage.IStorage) (IEventRepository, error) {
 			return nil, errors.New("incorrect event storage")
 		}
 
+		// События, сохраненные до появления версий, получают первую версию
+		if event.Version == 0 {
+			event.Version = 1
+		}
+
 		// Добавление события в мапу
 		eventsMap[event.ID] = event
+	}
+
+	// Применение операций из журнала, не попавших в снимок
+	err = eventJournal.Replay(func(rec journal.Record) error {
+		if rec.Op == journal.OpPurge {
+			delete(eventsMap, rec.Event.ID)
+			return nil
+		}
+
+		if rec.Event.Version == 0 {
+			rec.Event.Version = 1
+		}
+		eventsMap[rec.Event.ID] = rec.Event
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't replay journal: %v", err)
+	}
 
-		// Обновление максимального ID
-		if event.ID > maxID {
-			maxID = event.ID
+	// Наибольший ID
+	var maxID int
+	for id := range eventsMap {
+		if id > maxID {
+			maxID = id
 		}
 	}
 
 	// Создание объекта репозитория
 	repo := &eventRepository{
 		events:  eventsMap,
+		index:   newEventIndex(),
 		storage: storage,
+		journal: eventJournal,
+		logger:  logger,
 		mtx:     sync.RWMutex{},
 		counter: maxID + 1,
 	}
 
+	// Построение индекса по восстановленным событиям
+	repo.index.load(eventsMap, func(event model.Event, err error) {
+		logger.Error("error while indexing event's interval", "event_id", event.ID, "err", err)
+	})
+
 	return repo, nil
 }
 
-// Сохранение событий в хранилище
+// Сохранение событий в хранилище со сбросом журнала
 func (repo *eventRepository) SaveEvents() error {
-	// Преобразование мапы событий в слайс
-	eventSlc := make([]model.Event, 0, len(repo.events))
-	for _, event := range repo.events {
-		eventSlc = append(eventSlc, event)
-	}
+	// Эксклюзивная блокировка не дает записать в журнал операцию, не попавшую в снимок
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
 
-	// Сохранение событий в хранилище
-	err := repo.storage.Save(eventSlc)
-	if err != nil {
-		return fmt.Errorf("can't save events: %v", err)
+	return repo.compact()
+}
+
+// Количество изменений, не попавших в снимок хранилища
+func (repo *eventRepository) PendingChanges() int {
+	return repo.journal.Len()
+}
+
+// Количество неудаленных событий
+func (repo *eventRepository) Count() int {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	count := 0
+	for _, event := range repo.events {
+		if event.RemoveDate == "" {
+			count++
+		}
 	}
 
-	return nil
+	return count
 }
 
-// Добавление события
-func (repo *eventRepository) Insert(event model.Event) int {
+// Добавление события после проверки check (nil - без проверки)
+func (repo *eventRepository) Insert(event model.Event, check Check) (int, error) {
 	// Использование мьютекса для избежания гонки данных
 	repo.mtx.Lock()
 	defer repo.mtx.Unlock()
 
 	// Использование счетчика для назначения ID
 	event.ID = repo.counter
-	repo.counter++
 
-	// Добавление события в локальную мапу
-	repo.events[event.ID] = event
+	if err := repo.check(check, event); err != nil {
+		return 0, err
+	}
 
-	return event.ID
+	// Фиксация события до подтверждения вставки
+	if err := repo.commit(journal.OpInsert, event); err != nil {
+		return 0, err
+	}
+	repo.counter++
+
+	return event.ID, nil
 }
 
-// Обновление события
-func (repo *eventRepository) Update(id int, event model.Event) error {
+// Обновление события, если его текущая версия совпадает с version (0 - без проверки версии),
+// после проверки нового состояния check (nil - без проверки)
+func (repo *eventRepository) Update(id, version int, event model.Event, check Check) error {
 	// Использование мьютекса для избежания гонки данных
 	repo.mtx.Lock()
 	defer repo.mtx.Unlock()
@@ -101,147 +157,226 @@ func (repo *eventRepository) Update(id int, event model.Event) error {
 	// Поиск неудаленного события
 	updatingEvent, ok := repo.events[id]
 	if !ok || updatingEvent.RemoveDate != "" {
-		return errors.New("event not found")
+		return model.ErrEventNotFound
 	}
 
-	// Обновление события
-	repo.events[event.UserId] = model.Event{
-		ID:          updatingEvent.ID,
-		UserId:      event.UserId,
-		Description: event.Description,
-		Date:        event.Date,
-		RemoveDate:  updatingEvent.RemoveDate,
+	if err := checkVersion(updatingEvent, version); err != nil {
+		return err
 	}
 
-	return nil
+	updated, err := replaced(updatingEvent, event)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.check(check, updated); err != nil {
+		return err
+	}
+
+	return repo.commit(journal.OpUpdate, updated)
+}
+
+// Новое состояние события current, замененного событием event.
+// Исключения серии и привязка к серии меняются только операциями над повторениями,
+// а участники - операциями над участниками
+func replaced(current, event model.Event) (model.Event, error) {
+	// Отдельно измененное повторение не может само стать серией
+	if current.SeriesID != 0 && event.Recurrence != nil {
+		return model.Event{}, model.NewValidationError("recurrence", "is not allowed for an occurrence of a series")
+	}
+
+	return model.Event{
+		ID:           current.ID,
+		UID:          current.UID,
+		UserId:       event.UserId,
+		Description:  event.Description,
+		Date:         event.Date,
+		Start:        event.Start,
+		End:          event.End,
+		TimeZone:     event.TimeZone,
+		Recurrence:   event.Recurrence,
+		Reminders:    event.Reminders,
+		Attendees:    current.Attendees,
+		ExDates:      current.ExDates,
+		SeriesID:     current.SeriesID,
+		RecurrenceID: current.RecurrenceID,
+		RemoveDate:   current.RemoveDate,
+	}, nil
 }
 
-// Удаление события
-func (repo *eventRepository) Remove(id int) error {
+// Удаление события, если его текущая версия совпадает с version (0 - без проверки версии).
+// Вместе с серией удаляются ее отдельно измененные повторения
+func (repo *eventRepository) Remove(id, version int) error {
 	// Использование мьютекса для избежания гонки данных
 	repo.mtx.Lock()
 	defer repo.mtx.Unlock()
 
-	// Поиск неудаленного события
-	deletingEvent, ok := repo.events[id]
-	if !ok || deletingEvent.RemoveDate != "" {
-		return errors.New("event not found")
+	// Серия удаляется вместе с отдельно измененными повторениями одним пакетом
+	b := repo.newBatch()
+	if _, err := b.apply(Mutation{Kind: MutationRemove, ID: id, Version: version}); err != nil {
+		return err
 	}
 
-	// Назначение удаленной даты
-	deletingEvent.RemoveDate = time.Now().Format(model.DateLayout)
-	repo.events[id] = deletingEvent
+	return repo.commitBatch(b)
+}
 
-	return nil
+// Проставление даты удаления событию. Вызывается под мьютексом
+func (repo *eventRepository) remove(event model.Event) error {
+	event.RemoveDate = time.Now().Format(model.DateLayout)
+
+	return repo.commit(journal.OpRemove, event)
 }
 
-// Получение событий за дату date
-func (repo *eventRepository) GetForDay(date time.Time) ([]model.Event, error) {
-	// Использование мьютекса для избежания гонки данных
-	repo.mtx.RLock()
-	defer repo.mtx.RUnlock()
+// Проверка нового состояния события event функцией check. Вызывается под мьютексом
+func (repo *eventRepository) check(check Check, event model.Event) error {
+	if check == nil {
+		return nil
+	}
 
-	// События за день
-	eventsForDay := []model.Event{}
+	return check(event, lockedReader{repo: repo})
+}
 
-	// Итерация по событиям
-	for _, event := range repo.events {
-		// Если событие удалено, то продолжаем итерацию
-		if event.RemoveDate != "" {
-			continue
-		}
+// Чтение событий репозитория, мьютекс которого уже захвачен
+type lockedReader struct {
+	repo *eventRepository
+}
 
-		// Парсинг даты к формату
-		eventDate, err := time.Parse(model.DateLayout, event.Date)
-		if err != nil {
-			log.Printf("error while parsing event's date: %v", err)
-			continue
-		}
+// Получение событий пользователя userID, пересекающихся с периодом rng
+func (r lockedReader) GetRangeForUser(userID int, rng model.Range) ([]model.Event, error) {
+	return r.repo.rangeOf(r.repo.index.user(userID), rng), nil
+}
 
-		// Если дата события совпадает, то выполняется добавление в результат
-		if eventDate == date {
-			event = model.Event{
-				ID:          event.ID,
-				UserId:      event.UserId,
-				Date:        event.Date,
-				Description: event.Description,
-			}
-			eventsForDay = append(eventsForDay, event)
-		}
+// Проверка, что текущая версия события event совпадает с ожидаемой version.
+// Нулевая ожидаемая версия означает отсутствие проверки
+func checkVersion(event model.Event, version int) error {
+	if version != 0 && event.Version != version {
+		return fmt.Errorf("%w: expected version %d, current version %d", model.ErrVersionConflict, version, event.Version)
 	}
 
-	return eventsForDay, nil
+	return nil
 }
 
-// Получение событий за неделю, в которой имеется дата date
-func (repo *eventRepository) GetForWeek(date time.Time) ([]model.Event, error) {
-	// Использование мьютекса для избежания гонки данных
-	repo.mtx.RLock()
-	defer repo.mtx.RUnlock()
+// Фиксация изменения события: запись в журнал, в хранилище и в локальную мапу.
+// Версия события увеличивается на единицу относительно текущей. Вызывается под мьютексом
+func (repo *eventRepository) commit(op journal.Op, event model.Event) error {
+	event.Version = repo.events[event.ID].Version + 1
 
-	// События за неделю
-	eventsForWeek := []model.Event{}
+	// Запись в журнал. Без нее изменение не подтверждается
+	if err := repo.journal.Append(journal.Record{Op: op, Event: event}); err != nil {
+		return fmt.Errorf("can't append to journal: %v", err)
+	}
 
-	// Распарсинг даты date на год и неделю
-	year, week := date.ISOWeek()
+	// Изменение уже сохранено в журнале и попадет в следующий снимок,
+	// поэтому ошибка записи в хранилище его не отменяет
+	if err := repo.storage.Put(event); err != nil {
+		repo.logger.Error("error while putting event to storage", "event_id", event.ID, "err", err)
+	}
+
+	repo.events[event.ID] = event
+	repo.index.remove(event.ID)
+	repo.indexEvent(event)
 
-	// Итерация по событиям
+	return nil
+}
+
+// Добавление события в индекс. Событие с некорректным интервалом не попадает в выборки за период.
+// Вызывается под мьютексом
+func (repo *eventRepository) indexEvent(event model.Event) {
+	if err := repo.index.add(event); err != nil {
+		repo.logger.Error("error while indexing event's interval", "event_id", event.ID, "err", err)
+	}
+}
+
+// Запись снимка всех событий в хранилище и сброс журнала. Вызывается под мьютексом
+func (repo *eventRepository) compact() error {
+	// Преобразование мапы событий в слайс
+	eventSlc := make([]model.Event, 0, len(repo.events))
 	for _, event := range repo.events {
-		// Если событие удалено, то продолжаем итерацию
-		if event.RemoveDate != "" {
-			continue
-		}
+		eventSlc = append(eventSlc, event)
+	}
 
-		// Парсинг даты к формату
-		eventDate, err := time.Parse(model.DateLayout, event.Date)
-		if err != nil {
-			log.Printf("error while parsing event's date: %v", err)
-			continue
-		}
+	// Сохранение событий в хранилище
+	err := repo.storage.Save(eventSlc)
+	if err != nil {
+		return fmt.Errorf("can't save events: %v", err)
+	}
 
-		// Распарсинг даты события на год и неделю
-		eventYear, eventWeek := eventDate.ISOWeek()
+	// Записи журнала вошли в снимок и больше не нужны
+	if err := repo.journal.Reset(); err != nil {
+		return fmt.Errorf("can't reset journal: %v", err)
+	}
 
-		// Если год и неделя совпадают, то выполняется добавление в результат
-		if eventYear == year && eventWeek == week {
-			eventsForWeek = append(eventsForWeek, event)
-		}
+	return nil
+}
+
+// Получение неудаленного события по ID
+func (repo *eventRepository) GetByID(id int) (model.Event, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	event, ok := repo.events[id]
+	if !ok || event.RemoveDate != "" {
+		return model.Event{}, model.ErrEventNotFound
 	}
 
-	return eventsForWeek, nil
+	return event, nil
 }
 
-// Получение событий за месяц, в котором имеется дата date
-func (repo *eventRepository) GetForMonth(date time.Time) ([]model.Event, error) {
+// Получение событий, пересекающихся с периодом rng.
+// События на весь день без часового пояса отсчитываются в поясе периода
+func (repo *eventRepository) GetRange(rng model.Range) ([]model.Event, error) {
 	// Использование мьютекса для избежания гонки данных
 	repo.mtx.RLock()
 	defer repo.mtx.RUnlock()
 
-	// События за месяц
-	eventsForMonth := []model.Event{}
+	return repo.rangeOf(repo.index.all, rng), nil
+}
 
-	// Парсинг месяца из даты
-	month := date.Month()
+// Получение событий пользователя userID, пересекающихся с периодом rng
+func (repo *eventRepository) GetRangeForUser(userID int, rng model.Range) ([]model.Event, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
 
-	// Итерация по событиям
-	for _, event := range repo.events {
-		// Если событие удалено, то продолжаем итерацию
-		if event.RemoveDate != "" {
-			continue
-		}
+	return repo.rangeOf(repo.index.user(userID), rng), nil
+}
+
+// События временной шкалы tl, пересекающиеся с периодом rng. Вызывается под мьютексом
+func (repo *eventRepository) rangeOf(tl *timeline, rng model.Range) []model.Event {
+	// События за период
+	eventsForRange := []model.Event{}
 
-		// Парсинг даты к формату
-		eventDate, err := time.Parse(model.DateLayout, event.Date)
+	// Неповторяющиеся события находятся по индексу
+	for _, id := range tl.find(rng) {
+		eventsForRange = append(eventsForRange, repo.events[id])
+	}
+
+	// Серии разворачиваются в повторения, попавшие в период
+	for id := range tl.recurring {
+		occurrences, err := recurrence.Expand(repo.events[id], rng)
 		if err != nil {
-			log.Printf("error while parsing event's date: %v", err)
+			repo.logger.Error("error while expanding event's recurrence", "event_id", id, "err", err)
 			continue
 		}
 
-		// Если месяц совпадает, то выполняется добавление в результат
-		if month == eventDate.Month() {
-			eventsForMonth = append(eventsForMonth, event)
-		}
+		eventsForRange = append(eventsForRange, occurrences...)
 	}
 
-	return eventsForMonth, nil
+	return eventsForRange
+}
+
+// Получение событий за дату date
+func (repo *eventRepository) GetForDay(date time.Time) ([]model.Event, error) {
+	return repo.GetRange(model.DayRange(date))
+}
+
+// Получение событий за неделю, в которой имеется дата date
+func (repo *eventRepository) GetForWeek(date time.Time) ([]model.Event, error) {
+	return repo.GetRange(model.WeekRange(date))
+}
+
+// Получение событий за месяц, в котором имеется дата date
+func (repo *eventRepository) GetForMonth(date time.Time) ([]model.Event, error) {
+	return repo.GetRange(model.MonthRange(date))
 }
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/model"
+)
+
+func Test_eventRepository_check(t *testing.T) {
+	repo := openRepository(t, t.TempDir())
+	errBusy := errors.New("busy")
+
+	// Проверка видит новое состояние события и читает события без повторной блокировки
+	var checked model.Event
+	var seen []model.Event
+	record := func(event model.Event, events IRangeReader) error {
+		checked = event
+		rng := model.Range{From: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}
+		var err error
+		seen, err = events.GetRangeForUser(1, rng)
+		return err
+	}
+	reject := func(model.Event, IRangeReader) error { return errBusy }
+
+	id, err := repo.Insert(model.Event{UserId: 1, Date: "2023-05-01", Description: "a"}, record)
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+	if checked.ID != id || len(seen) != 0 {
+		t.Errorf("check of insert got event %+v and events %v", checked, seen)
+	}
+
+	if err := repo.Update(id, 0, model.Event{UserId: 1, Date: "2023-05-02", Description: "b"}, record); err != nil {
+		t.Fatalf("updating event: %v", err)
+	}
+	if checked.ID != id || checked.Date != "2023-05-02" || len(seen) != 1 || seen[0].Date != "2023-05-01" {
+		t.Errorf("check of update got event %+v and events %v", checked, seen)
+	}
+
+	// Ошибка проверки отменяет запись
+	if _, err := repo.Insert(model.Event{UserId: 1, Date: "2023-05-03", Description: "c"}, reject); !errors.Is(err, errBusy) {
+		t.Errorf("Insert() error = %v, want check error", err)
+	}
+	if err := repo.Update(id, 0, model.Event{UserId: 1, Date: "2023-05-04", Description: "d"}, reject); !errors.Is(err, errBusy) {
+		t.Errorf("Update() error = %v, want check error", err)
+	}
+
+	seriesID, err := repo.Insert(model.Event{
+		UserId: 1, Date: "2023-05-01", Recurrence: &model.Recurrence{Freq: model.FreqDaily}, Description: "s",
+	}, nil)
+	if err != nil {
+		t.Fatalf("inserting series: %v", err)
+	}
+	if _, err := repo.DetachOccurrence(seriesID, "2023-05-02", 0, model.Event{UserId: 1, Date: "2023-05-02", Description: "moved"}, reject); !errors.Is(err, errBusy) {
+		t.Errorf("DetachOccurrence() error = %v, want check error", err)
+	}
+
+	if event, _ := repo.GetByID(id); event.Date != "2023-05-02" || event.Version != 2 {
+		t.Errorf("event after rejected update = %+v", event)
+	}
+	if series, _ := repo.GetByID(seriesID); len(series.ExDates) != 0 || repo.Count() != 2 {
+		t.Errorf("series after rejected detach = %+v, %d events", series, repo.Count())
+	}
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"dev11/calendar/internal/model"
+	"sort"
+	"time"
+)
+
+// Наибольший сдвиг "плавающего" события на весь день относительно его интервала в UTC:
+// смещения часовых поясов лежат в пределах от -12 до +14 часов
+const floatingSlack = 14 * time.Hour
+
+// Запись индекса - интервал неповторяющегося события в наносекундах Unix, разобранный при индексации.
+// Для "плавающего" события интервал хранится в UTC и переносится в пояс запроса
+type indexEntry struct {
+	start    int64
+	end      int64
+	id       int
+	floating bool
+}
+
+// Интервал записи в часовом поясе loc
+func (e indexEntry) interval(loc *time.Location) (time.Time, time.Time) {
+	start := time.Unix(0, e.start).UTC()
+	if !e.floating {
+		return start, time.Unix(0, e.end).UTC()
+	}
+
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Порядок записей: по началу интервала, при равенстве - по ID
+func (e indexEntry) less(other indexEntry) bool {
+	if e.start != other.start {
+		return e.start < other.start
+	}
+	return e.id < other.id
+}
+
+// Временная шкала неудаленных событий.
+// Неповторяющиеся события упорядочены по началу, поэтому пересекающиеся с периодом события
+// находятся двоичным поиском: начаться они могут не раньше чем за maxDuration до периода.
+// maxDuration не уменьшается при удалении событий и пересчитывается только при перестроении индекса.
+// Серии хранятся отдельным множеством и разворачиваются при каждом запросе
+type timeline struct {
+	entries     []indexEntry
+	indexed     map[int]indexEntry
+	recurring   map[int]struct{}
+	maxDuration int64
+	floating    int
+}
+
+// Конструктор временной шкалы
+func newTimeline() *timeline {
+	return &timeline{
+		entries:   []indexEntry{},
+		indexed:   map[int]indexEntry{},
+		recurring: map[int]struct{}{},
+	}
+}
+
+// Добавление записи неповторяющегося события с сохранением порядка
+func (tl *timeline) add(entry indexEntry) {
+	i := sort.Search(len(tl.entries), func(i int) bool { return !tl.entries[i].less(entry) })
+
+	tl.entries = append(tl.entries, indexEntry{})
+	copy(tl.entries[i+1:], tl.entries[i:])
+	tl.entries[i] = entry
+	tl.track(entry)
+}
+
+// Добавление записи в конец шкалы без сохранения порядка. После загрузки всех записей вызывается sort
+func (tl *timeline) append(entry indexEntry) {
+	tl.entries = append(tl.entries, entry)
+	tl.track(entry)
+}
+
+// Упорядочивание записей шкалы
+func (tl *timeline) sort() {
+	sort.Slice(tl.entries, func(i, j int) bool { return tl.entries[i].less(tl.entries[j]) })
+}
+
+// Учет записи в мапе записей, наибольшей длительности и числе "плавающих" событий
+func (tl *timeline) track(entry indexEntry) {
+	tl.indexed[entry.id] = entry
+
+	if duration := entry.end - entry.start; duration > tl.maxDuration {
+		tl.maxDuration = duration
+	}
+	if entry.floating {
+		tl.floating++
+	}
+}
+
+// Добавление серии
+func (tl *timeline) addRecurring(id int) {
+	tl.recurring[id] = struct{}{}
+}
+
+// Удаление события id со шкалы, если оно на ней есть
+func (tl *timeline) remove(id int) {
+	delete(tl.recurring, id)
+
+	entry, ok := tl.indexed[id]
+	if !ok {
+		return
+	}
+
+	i := sort.Search(len(tl.entries), func(i int) bool { return !tl.entries[i].less(entry) })
+	tl.entries = append(tl.entries[:i], tl.entries[i+1:]...)
+	delete(tl.indexed, id)
+
+	if entry.floating {
+		tl.floating--
+	}
+}
+
+// Признак отсутствия событий на шкале
+func (tl *timeline) empty() bool {
+	return len(tl.indexed) == 0 && len(tl.recurring) == 0
+}
+
+// ID неповторяющихся событий, пересекающихся с периодом rng, в порядке начала
+func (tl *timeline) find(rng model.Range) []int {
+	from := rng.From.UnixNano() - tl.maxDuration
+	to := rng.To.UnixNano()
+	if tl.floating > 0 {
+		from -= int64(floatingSlack)
+		to += int64(floatingSlack)
+	}
+
+	ids := []int{}
+	loc := rng.From.Location()
+
+	i := sort.Search(len(tl.entries), func(i int) bool { return tl.entries[i].start >= from })
+	for ; i < len(tl.entries) && tl.entries[i].start <= to; i++ {
+		if start, end := tl.entries[i].interval(loc); rng.Overlaps(start, end) {
+			ids = append(ids, tl.entries[i].id)
+		}
+	}
+
+	return ids
+}
+
+// Ключ события в индексе UID: организатор и UID события
+type uidKey struct {
+	userID int
+	uid    string
+}
+
+// Индекс неудаленных событий: общая временная шкала и шкалы каждого пользователя.
+// Событие попадает в шкалы организатора и всех участников.
+// Отдельно события индексируются по организатору и UID, в том числе события с некорректным интервалом
+type eventIndex struct {
+	all    *timeline
+	byUser map[int]*timeline
+	users  map[int][]int
+	byUID  map[uidKey]int
+	uids   map[int]uidKey
+}
+
+// Конструктор индекса событий
+func newEventIndex() *eventIndex {
+	return &eventIndex{
+		all:    newTimeline(),
+		byUser: map[int]*timeline{},
+		users:  map[int][]int{},
+		byUID:  map[uidKey]int{},
+		uids:   map[int]uidKey{},
+	}
+}
+
+// Добавление события в индекс. Удаленные события не индексируются.
+// Событие с некорректным интервалом не индексируется, и возвращается ошибка разбора
+func (idx *eventIndex) add(event model.Event) error {
+	return idx.insert(event, (*timeline).add)
+}
+
+// Загрузка событий events в пустой индекс. Записи упорядочиваются один раз после загрузки.
+// Для событий с некорректным интервалом вызывается onError
+func (idx *eventIndex) load(events map[int]model.Event, onError func(event model.Event, err error)) {
+	for _, event := range events {
+		if err := idx.insert(event, (*timeline).append); err != nil {
+			onError(event, err)
+		}
+	}
+
+	idx.all.sort()
+	for _, user := range idx.byUser {
+		user.sort()
+	}
+}
+
+// Добавление события в общую шкалу и шкалы организатора и участников функцией put
+func (idx *eventIndex) insert(event model.Event, put func(tl *timeline, entry indexEntry)) error {
+	if event.RemoveDate != "" {
+		return nil
+	}
+
+	key := uidKey{userID: event.UserId, uid: event.GetUID()}
+	idx.byUID[key] = event.ID
+	idx.uids[event.ID] = key
+
+	var entry indexEntry
+	if !event.IsRecurring() {
+		start, end, err := event.Interval(time.UTC)
+		if err != nil {
+			return err
+		}
+
+		entry = indexEntry{
+			start:    start.UnixNano(),
+			end:      end.UnixNano(),
+			id:       event.ID,
+			floating: event.IsAllDay() && event.TimeZone == "",
+		}
+	}
+
+	userIDs := []int{event.UserId}
+	for _, attendee := range event.Attendees {
+		if attendee.UserID != event.UserId {
+			userIDs = append(userIDs, attendee.UserID)
+		}
+	}
+	idx.users[event.ID] = userIDs
+
+	if event.IsRecurring() {
+		idx.all.addRecurring(event.ID)
+	} else {
+		put(idx.all, entry)
+	}
+
+	for _, userID := range userIDs {
+		user, ok := idx.byUser[userID]
+		if !ok {
+			user = newTimeline()
+			idx.byUser[userID] = user
+		}
+
+		if event.IsRecurring() {
+			user.addRecurring(event.ID)
+		} else {
+			put(user, entry)
+		}
+	}
+
+	return nil
+}
+
+// Удаление события id из индекса
+func (idx *eventIndex) remove(id int) {
+	if key, ok := idx.uids[id]; ok {
+		if idx.byUID[key] == id {
+			delete(idx.byUID, key)
+		}
+		delete(idx.uids, id)
+	}
+
+	userIDs, ok := idx.users[id]
+	if !ok {
+		return
+	}
+
+	idx.all.remove(id)
+	for _, userID := range userIDs {
+		if user := idx.byUser[userID]; user != nil {
+			user.remove(id)
+			if user.empty() {
+				delete(idx.byUser, userID)
+			}
+		}
+	}
+	delete(idx.users, id)
+}
+
+// ID неудаленного события организатора userID с UID uid
+func (idx *eventIndex) findUID(userID int, uid string) (int, bool) {
+	id, ok := idx.byUID[uidKey{userID: userID, uid: uid}]
+	return id, ok
+}
+
+// Временная шкала пользователя userID. Для пользователя без событий возвращается пустая шкала
+func (idx *eventIndex) user(userID int) *timeline {
+	if user, ok := idx.byUser[userID]; ok {
+		return user
+	}
+	return newTimeline()
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/recurrence"
+	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Генерация count событий пользователей 1..users за год, начиная с 2023-01-01.
+// Среди событий есть события со временем, события на весь день с поясом и без него, серии и удаленные события
+func generateEvents(count, users int) []model.Event {
+	rnd := rand.New(rand.NewSource(1))
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	zones := []string{"", "Europe/Moscow", "America/New_York", "Pacific/Kiritimati"}
+
+	events := make([]model.Event, 0, count)
+	for i := 1; i <= count; i++ {
+		event := model.Event{
+			ID:          i,
+			Version:     1,
+			UserId:      rnd.Intn(users) + 1,
+			Description: fmt.Sprintf("event %d", i),
+		}
+
+		day := base.AddDate(0, 0, rnd.Intn(365))
+		switch kind := rnd.Intn(100); {
+		case kind < 10:
+			event.Date = day.Format(model.DateLayout)
+			event.TimeZone = zones[rnd.Intn(len(zones))]
+		case kind < 11:
+			event.Date = day.Format(model.DateLayout)
+			event.Recurrence = &model.Recurrence{Freq: model.FreqWeekly}
+		default:
+			start := day.Add(time.Duration(rnd.Intn(24*60)) * time.Minute)
+			event.Start = start.Format(model.TimeLayout)
+			event.End = start.Add(time.Duration(rnd.Intn(180)) * time.Minute).Format(model.TimeLayout)
+			event.Date = start.Format(model.DateLayout)
+		}
+
+		if rnd.Intn(20) == 0 {
+			event.RemoveDate = "2023-01-01"
+		}
+		if users > 1 && rnd.Intn(10) == 0 {
+			event.Attendees = []model.Attendee{{UserID: event.UserId%users + 1, Status: model.RSVPAccepted}}
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// Открытие репозитория поверх снимка хранилища с событиями events
+func openRepositoryWith(tb testing.TB, events []model.Event) *eventRepository {
+	dir := tb.TempDir()
+	discard := logger.New(io.Discard, logger.LevelDebug)
+
+	store, err := storage.New(storage.TypeFile, filepath.Join(dir, "data.json"))
+	if err != nil {
+		tb.Fatalf("opening storage: %v", err)
+	}
+	if err := store.Save(events); err != nil {
+		tb.Fatalf("saving events: %v", err)
+	}
+
+	eventJournal, err := journal.NewJournal(filepath.Join(dir, "journal.log"), discard)
+	if err != nil {
+		tb.Fatalf("opening journal: %v", err)
+	}
+	tb.Cleanup(func() { eventJournal.Close() })
+
+	repo, err := NewEventRepository(store, eventJournal, discard)
+	if err != nil {
+		tb.Fatalf("opening repository: %v", err)
+	}
+
+	return repo.(*eventRepository)
+}
+
+// Получение событий за период полным перебором - эталон для индекса
+func scanRange(repo *eventRepository, rng model.Range, owner func(model.Event) bool) []model.Event {
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	eventsForRange := []model.Event{}
+	for _, event := range repo.events {
+		if event.RemoveDate != "" || !owner(event) {
+			continue
+		}
+
+		if event.IsRecurring() {
+			occurrences, err := recurrence.Expand(event, rng)
+			if err == nil {
+				eventsForRange = append(eventsForRange, occurrences...)
+			}
+			continue
+		}
+
+		start, end, err := event.Interval(rng.From.Location())
+		if err == nil && rng.Overlaps(start, end) {
+			eventsForRange = append(eventsForRange, event)
+		}
+	}
+
+	return eventsForRange
+}
+
+// Ключи событий выборки в упорядоченном виде
+func eventKeys(events []model.Event) []string {
+	keys := make([]string, 0, len(events))
+	for _, event := range events {
+		keys = append(keys, fmt.Sprintf("%d/%s/%d", event.ID, event.RecurrenceID, event.Version))
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func Test_eventRepository_GetRangeMatchesScan(t *testing.T) {
+	repo := openRepositoryWith(t, generateEvents(2000, 5))
+
+	// Изменения после построения индекса
+	if _, err := repo.Insert(model.Event{UserId: 1, Date: "2023-03-01", Start: "2023-02-20T10:00:00Z",
+		End: "2023-03-10T10:00:00Z", Description: "long"}, nil); err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+	if err := repo.Update(3, 0, model.Event{UserId: 1, Date: "2023-03-05", Description: "moved"}, nil); err != nil {
+		t.Fatalf("updating event: %v", err)
+	}
+	if err := repo.Remove(4, 0); err != nil {
+		t.Fatalf("removing event: %v", err)
+	}
+	if err := repo.Purge(4); err != nil {
+		t.Fatalf("purging event: %v", err)
+	}
+	if _, err := repo.UpdateAttendees(5, func(model.Event) ([]model.Attendee, error) {
+		return []model.Attendee{{UserID: 1, Status: model.RSVPNeedsAction}}, nil
+	}); err != nil && !errors.Is(err, model.ErrEventNotFound) {
+		t.Fatalf("inviting attendee: %v", err)
+	}
+
+	locations := []string{"UTC", "Europe/Moscow", "America/Los_Angeles", "Pacific/Kiritimati"}
+	for _, name := range locations {
+		loc, _ := time.LoadLocation(name)
+
+		for _, date := range []time.Time{
+			time.Date(2023, 3, 5, 0, 0, 0, 0, loc),
+			time.Date(2023, 7, 31, 0, 0, 0, 0, loc),
+			time.Date(2023, 12, 31, 0, 0, 0, 0, loc),
+		} {
+			ranges := map[string]model.Range{
+				"day":       model.DayRange(date),
+				"week":      model.WeekRange(date),
+				"month":     model.MonthRange(date),
+				"inclusive": {From: date, To: date.AddDate(0, 0, 1)},
+			}
+
+			for rangeName, rng := range ranges {
+				name := fmt.Sprintf("%s %s %s", name, date.Format(model.DateLayout), rangeName)
+
+				got, _ := repo.GetRange(rng)
+				want := scanRange(repo, rng, func(model.Event) bool { return true })
+				if fmt.Sprint(eventKeys(got)) != fmt.Sprint(eventKeys(want)) {
+					t.Errorf("%s: GetRange() returned %d events, want %d", name, len(got), len(want))
+				}
+
+				got, _ = repo.GetRangeForUser(1, rng)
+				want = scanRange(repo, rng, func(e model.Event) bool { return e.VisibleTo(1) })
+				if fmt.Sprint(eventKeys(got)) != fmt.Sprint(eventKeys(want)) {
+					t.Errorf("%s: GetRangeForUser() returned %d events, want %d", name, len(got), len(want))
+				}
+			}
+		}
+	}
+}
+
+// Сравнение выборки за день по индексу с полным перебором на 200 тысячах событий 100 пользователей
+func benchmarkGetForDay(b *testing.B, get func(repo *eventRepository, rng model.Range) []model.Event) {
+	repo := openRepositoryWith(b, generateEvents(200000, 100))
+	day := time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		get(repo, model.DayRange(day.AddDate(0, 0, i%30)))
+	}
+}
+
+func Benchmark_GetForDay_Index(b *testing.B) {
+	benchmarkGetForDay(b, func(repo *eventRepository, rng model.Range) []model.Event {
+		events, _ := repo.GetRange(rng)
+		return events
+	})
+}
+
+func Benchmark_GetForDay_Scan(b *testing.B) {
+	benchmarkGetForDay(b, func(repo *eventRepository, rng model.Range) []model.Event {
+		return scanRange(repo, rng, func(model.Event) bool { return true })
+	})
+}
+
+func Benchmark_GetForDayForUser_Index(b *testing.B) {
+	benchmarkGetForDay(b, func(repo *eventRepository, rng model.Range) []model.Event {
+		events, _ := repo.GetRangeForUser(7, rng)
+		return events
+	})
+}
+
+func Benchmark_GetForDayForUser_Scan(b *testing.B) {
+	benchmarkGetForDay(b, func(repo *eventRepository, rng model.Range) []model.Event {
+		return scanRange(repo, rng, func(e model.Event) bool { return e.VisibleTo(7) })
+	})
+}
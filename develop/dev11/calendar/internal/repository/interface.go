@@ -5,11 +5,34 @@ import (
 	"time"
 )
 
+// Чтение событий пользователя за период
+type IRangeReader interface {
+	GetRangeForUser(userID int, rng model.Range) ([]model.Event, error)
+}
+
+// Проверка нового состояния события event перед записью. Вызывается под мьютексом репозитория,
+// поэтому прочитанные через events события не меняются до записи. Ошибка отменяет запись
+type Check func(event model.Event, events IRangeReader) error
+
 type IEventRepository interface {
 	SaveEvents() error
-	Insert(event model.Event) int
-	Update(id int, event model.Event) error
-	Remove(id int) error
+	PendingChanges() int
+	Count() int
+	Insert(event model.Event, check Check) (int, error)
+	Update(id, version int, event model.Event, check Check) error
+	Remove(id, version int) error
+	Apply(mutations []Mutation, atomic bool) ([]MutationResult, error)
+	UpdateAttendees(id int, change func(event model.Event) ([]model.Attendee, error)) (model.Event, error)
+	DetachOccurrence(id int, date string, version int, event model.Event, check Check) (int, error)
+	ExcludeOccurrence(id int, date string, version int) error
+	GetDeleted() ([]model.Event, error)
+	GetDeletedByID(id int) (model.Event, error)
+	Restore(id int) error
+	Purge(id int) error
+	PurgeDeletedBefore(before time.Time) (int, error)
+	GetByID(id int) (model.Event, error)
+	GetRange(rng model.Range) ([]model.Event, error)
+	GetRangeForUser(userID int, rng model.Range) ([]model.Event, error)
 	GetForDay(day time.Time) ([]model.Event, error)
 	GetForWeek(day time.Time) ([]model.Event, error)
 	GetForMonth(day time.Time) ([]model.Event, error)
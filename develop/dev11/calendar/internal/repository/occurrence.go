@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/recurrence"
+	"fmt"
+)
+
+// Изменение отдельного повторения date серии id, если текущая версия серии совпадает с version.
+// Повторение исключается из серии и сохраняется самостоятельным событием с участниками серии
+// одним пакетом; если оно уже было изменено ранее, то обновляется существующее событие.
+// Перед записью событие повторения проходит проверку check (nil - без проверки).
+// Возвращает ID события повторения
+func (repo *eventRepository) DetachOccurrence(id int, date string, version int, event model.Event, check Check) (int, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	series, err := repo.getSeries(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := checkVersion(series, version); err != nil {
+		return 0, err
+	}
+
+	// Повторение уже изменялось - обновляется его событие
+	if override, ok := repo.findOverride(id, date); ok {
+		event.ID = override.ID
+		event.Attendees = override.Attendees
+		event.SeriesID = id
+		event.RecurrenceID = date
+		event.Recurrence = nil
+
+		if err := repo.check(check, event); err != nil {
+			return 0, err
+		}
+
+		return override.ID, repo.commit(journal.OpUpdate, event)
+	}
+
+	if err := repo.checkOccurrence(series, date); err != nil {
+		return 0, err
+	}
+
+	b := repo.newBatch()
+
+	// Исключение даты из серии
+	series.ExDates = append(append([]string{}, series.ExDates...), date)
+	b.put(journal.OpUpdate, series)
+
+	// Самостоятельное событие повторения
+	event.ID = b.counter
+	event.Attendees = series.Attendees
+	event.SeriesID = id
+	event.RecurrenceID = date
+	event.Recurrence = nil
+
+	if err := repo.check(check, event); err != nil {
+		return 0, err
+	}
+
+	b.put(journal.OpInsert, event)
+	b.counter++
+
+	if err := repo.commitBatch(b); err != nil {
+		return 0, err
+	}
+
+	return event.ID, nil
+}
+
+// Удаление отдельного повторения date серии id, если текущая версия серии совпадает с version
+func (repo *eventRepository) ExcludeOccurrence(id int, date string, version int) error {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	series, err := repo.getSeries(id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(series, version); err != nil {
+		return err
+	}
+
+	// Повторение уже изменялось - удаляется его событие, дата уже исключена из серии
+	if override, ok := repo.findOverride(id, date); ok {
+		return repo.remove(override)
+	}
+
+	if err := repo.checkOccurrence(series, date); err != nil {
+		return err
+	}
+
+	series.ExDates = append(append([]string{}, series.ExDates...), date)
+
+	return repo.commit(journal.OpUpdate, series)
+}
+
+// Получение неудаленной серии по ID. Вызывается под мьютексом
+func (repo *eventRepository) getSeries(id int) (model.Event, error) {
+	series, ok := repo.events[id]
+	if !ok || series.RemoveDate != "" {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	if !series.IsRecurring() {
+		return model.Event{}, fmt.Errorf("%w: event %d", model.ErrNotRecurring, id)
+	}
+
+	return series, nil
+}
+
+// Проверка, что дата date является повторением серии series
+func (repo *eventRepository) checkOccurrence(series model.Event, date string) error {
+	ok, err := recurrence.IsOccurrence(series, date)
+	if err != nil {
+		return fmt.Errorf("can't check occurrence: %v", err)
+	}
+
+	if !ok {
+		return model.ErrOccurrenceNotFound
+	}
+
+	return nil
+}
+
+// Поиск неудаленного события отдельно измененного повторения date серии id. Вызывается под мьютексом
+func (repo *eventRepository) findOverride(id int, date string) (model.Event, bool) {
+	for _, event := range repo.overrides(id) {
+		if event.RecurrenceID == date {
+			return event, true
+		}
+	}
+
+	return model.Event{}, false
+}
+
+// Неудаленные события отдельно измененных повторений серии id. Вызывается под мьютексом
+func (repo *eventRepository) overrides(id int) []model.Event {
+	overrides := []model.Event{}
+	for _, event := range repo.events {
+		if event.SeriesID == id && event.RemoveDate == "" {
+			overrides = append(overrides, event)
+		}
+	}
+
+	return overrides
+}
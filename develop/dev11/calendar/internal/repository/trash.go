@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"fmt"
+	"time"
+)
+
+// Получение удаленных событий
+func (repo *eventRepository) GetDeleted() ([]model.Event, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	deleted := []model.Event{}
+	for _, event := range repo.events {
+		if event.RemoveDate != "" {
+			deleted = append(deleted, event)
+		}
+	}
+
+	return deleted, nil
+}
+
+// Получение удаленного события по ID
+func (repo *eventRepository) GetDeletedByID(id int) (model.Event, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.RLock()
+	defer repo.mtx.RUnlock()
+
+	event, ok := repo.events[id]
+	if !ok || event.RemoveDate == "" {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	return event, nil
+}
+
+// Восстановление удаленного события. Вместе с серией одним пакетом восстанавливаются ее отдельно
+// измененные повторения, удаленные вместе с ней; удаленные до нее по отдельности остаются в корзине.
+// Повторение удаленной серии не восстанавливается
+func (repo *eventRepository) Restore(id int) error {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	event, ok := repo.events[id]
+	if !ok || event.RemoveDate == "" {
+		return model.ErrEventNotFound
+	}
+
+	if event.SeriesID != 0 {
+		if series, ok := repo.events[event.SeriesID]; !ok || series.RemoveDate != "" {
+			return model.ErrSeriesDeleted
+		}
+	}
+
+	b := repo.newBatch()
+	if event.IsRecurring() {
+		for _, override := range repo.events {
+			if override.SeriesID == id && override.RemovedWithSeries {
+				override.RemoveDate = ""
+				override.RemovedWithSeries = false
+				b.put(journal.OpUpdate, override)
+			}
+		}
+	}
+
+	event.RemoveDate = ""
+	b.put(journal.OpUpdate, event)
+
+	return repo.commitBatch(b)
+}
+
+// Окончательное удаление события из корзины. Вместе с серией одним пакетом удаляются
+// ее удаленные повторения
+func (repo *eventRepository) Purge(id int) error {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	event, ok := repo.events[id]
+	if !ok || event.RemoveDate == "" {
+		return model.ErrEventNotFound
+	}
+
+	b := repo.newBatch()
+	if event.IsRecurring() {
+		for _, override := range repo.events {
+			if override.SeriesID == id && override.RemoveDate != "" {
+				b.purge(override.ID)
+			}
+		}
+	}
+	b.purge(id)
+
+	return repo.commitBatch(b)
+}
+
+// Окончательное удаление одним пакетом событий, удаленных раньше момента before.
+// Возвращает количество удаленных
+func (repo *eventRepository) PurgeDeletedBefore(before time.Time) (int, error) {
+	// Использование мьютекса для избежания гонки данных
+	repo.mtx.Lock()
+	defer repo.mtx.Unlock()
+
+	b := repo.newBatch()
+	for _, event := range repo.events {
+		if event.RemoveDate == "" {
+			continue
+		}
+
+		removed, err := time.Parse(model.DateLayout, event.RemoveDate)
+		if err != nil {
+			return 0, fmt.Errorf("parsing remove date of event %d: %v", event.ID, err)
+		}
+
+		if removed.Before(before) {
+			b.purge(event.ID)
+		}
+	}
+
+	if err := repo.commitBatch(b); err != nil {
+		return 0, err
+	}
+
+	return len(b.purged), nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
+)
+
+// Открытие репозитория с файловым хранилищем и журналом в директории dir
+func openRepository(t *testing.T, dir string) IEventRepository {
+	discard := logger.New(io.Discard, logger.LevelDebug)
+
+	store, err := storage.New(storage.TypeFile, filepath.Join(dir, "data.json"))
+	if err != nil {
+		t.Fatalf("opening storage: %v", err)
+	}
+
+	eventJournal, err := journal.NewJournal(filepath.Join(dir, "journal.log"), discard)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	t.Cleanup(func() { eventJournal.Close() })
+
+	repo, err := NewEventRepository(store, eventJournal, discard)
+	if err != nil {
+		t.Fatalf("opening repository: %v", err)
+	}
+
+	return repo
+}
+
+func Test_eventRepository_Purge(t *testing.T) {
+	dir := t.TempDir()
+	repo := openRepository(t, dir)
+
+	id, err := repo.Insert(model.Event{UserId: 1, Date: "2023-05-01", Description: "a"}, nil)
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+
+	// Неудаленное событие нельзя удалить окончательно
+	if err := repo.Purge(id); !errors.Is(err, model.ErrEventNotFound) {
+		t.Fatalf("expected ErrEventNotFound for a live event, got %v", err)
+	}
+
+	if err := repo.Remove(id, 0); err != nil {
+		t.Fatalf("removing event: %v", err)
+	}
+	if err := repo.Purge(id); err != nil {
+		t.Fatalf("purging event: %v", err)
+	}
+
+	// Окончательное удаление переживает перезапуск за счет журнала
+	repo = openRepository(t, dir)
+	if _, err := repo.GetDeletedByID(id); !errors.Is(err, model.ErrEventNotFound) {
+		t.Errorf("expected purged event to be gone after restart, got %v", err)
+	}
+}
+
+func Test_eventRepository_RestoreSeries(t *testing.T) {
+	repo := openRepository(t, t.TempDir())
+
+	seriesID, err := repo.Insert(model.Event{
+		UserId: 1, Date: "2023-05-01", Recurrence: &model.Recurrence{Freq: model.FreqDaily}, Description: "s",
+	}, nil)
+	if err != nil {
+		t.Fatalf("inserting series: %v", err)
+	}
+
+	overrideID, err := repo.DetachOccurrence(seriesID, "2023-05-02", 0, model.Event{UserId: 1, Date: "2023-05-02", Description: "moved"}, nil)
+	if err != nil {
+		t.Fatalf("detaching occurrence: %v", err)
+	}
+	removedID, err := repo.DetachOccurrence(seriesID, "2023-05-03", 0, model.Event{UserId: 1, Date: "2023-05-03", Description: "removed"}, nil)
+	if err != nil {
+		t.Fatalf("detaching occurrence: %v", err)
+	}
+
+	// Повторение, удаленное отдельно в тот же день до удаления серии
+	if err := repo.Remove(removedID, 0); err != nil {
+		t.Fatalf("removing occurrence: %v", err)
+	}
+
+	if err := repo.Remove(seriesID, 0); err != nil {
+		t.Fatalf("removing series: %v", err)
+	}
+
+	// Повторение удаленной серии не восстанавливается отдельно
+	if err := repo.Restore(overrideID); !errors.Is(err, model.ErrSeriesDeleted) {
+		t.Fatalf("expected ErrSeriesDeleted, got %v", err)
+	}
+
+	// Серия восстанавливается вместе с повторением
+	if err := repo.Restore(seriesID); err != nil {
+		t.Fatalf("restoring series: %v", err)
+	}
+	if _, err := repo.GetByID(overrideID); err != nil {
+		t.Errorf("expected override to be restored with its series, got %v", err)
+	}
+	if _, err := repo.GetDeletedByID(removedID); err != nil {
+		t.Errorf("expected separately removed override to stay in trash, got %v", err)
+	}
+}
+
+func Test_eventRepository_PurgeDeletedBefore(t *testing.T) {
+	dir := t.TempDir()
+	repo := openRepository(t, dir).(*eventRepository)
+
+	for i := 0; i < 2; i++ {
+		id, err := repo.Insert(model.Event{UserId: 1, Date: "2023-05-01", Description: "a"}, nil)
+		if err != nil {
+			t.Fatalf("inserting event: %v", err)
+		}
+		if err := repo.Remove(id, 0); err != nil {
+			t.Fatalf("removing event: %v", err)
+		}
+	}
+
+	// События удалены сегодня, поэтому не старше вчерашнего дня
+	purged, err := repo.PurgeDeletedBefore(time.Now().AddDate(0, 0, -1))
+	if err != nil || purged != 0 {
+		t.Fatalf("expected nothing to purge, got %d, %v", purged, err)
+	}
+
+	// Все события удаляются одним пакетом журнала
+	recorder := &countingJournal{IJournal: repo.journal}
+	repo.journal = recorder
+
+	purged, err = repo.PurgeDeletedBefore(time.Now().AddDate(0, 0, 1))
+	if err != nil || purged != 2 {
+		t.Fatalf("expected 2 purged events, got %d, %v", purged, err)
+	}
+	if recorder.appends != 0 || recorder.batches != 1 {
+		t.Errorf("purge wrote %d single records and %d batches, want one batch", recorder.appends, recorder.batches)
+	}
+
+	deleted, _ := repo.GetDeleted()
+	if len(deleted) != 0 {
+		t.Errorf("expected empty trash, got %+v", deleted)
+	}
+
+	// Удаление переживает перезапуск за счет журнала
+	if deleted, _ := openRepository(t, dir).GetDeleted(); len(deleted) != 0 {
+		t.Errorf("expected empty trash after restart, got %+v", deleted)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+	"fmt"
+)
+
+// Приглашение пользователей attendeeIDs на событие id организатором userID.
+// Новые участники ожидают ответа, ответы уже приглашенных сохраняются. Возвращает событие
+func (s *eventService) InviteAttendees(userID, id int, attendeeIDs []int) (model.Event, error) {
+	event, err := s.repo.UpdateAttendees(id, func(event model.Event) ([]model.Attendee, error) {
+		if event.UserId != userID {
+			return nil, model.ErrForbidden
+		}
+
+		attendees := append([]model.Attendee{}, event.Attendees...)
+		invited := map[int]bool{}
+		for _, attendee := range attendees {
+			invited[attendee.UserID] = true
+		}
+
+		for _, attendeeID := range attendeeIDs {
+			if attendeeID == event.UserId {
+				return nil, model.NewValidationError("user_ids", "should not contain the organizer")
+			}
+
+			if !invited[attendeeID] {
+				invited[attendeeID] = true
+				attendees = append(attendees, model.Attendee{UserID: attendeeID, Status: model.RSVPNeedsAction})
+			}
+		}
+
+		if len(attendees) > model.MaxAttendees {
+			return nil, model.NewValidationError("user_ids", fmt.Sprintf("should not make more than %d attendees", model.MaxAttendees))
+		}
+
+		return attendees, nil
+	})
+	if err != nil {
+		s.logError("error while inviting attendees", err, "user_id", userID, "event_id", id)
+		return model.Event{}, err
+	}
+
+	s.publish(bus.ChangeUpdated, event.UserId, id)
+	return event, nil
+}
+
+// Ответ участника userID на приглашение на событие id
+func (s *eventService) RespondToInvitation(userID, id int, status string) (model.Event, error) {
+	event, err := s.repo.UpdateAttendees(id, func(event model.Event) ([]model.Attendee, error) {
+		if _, ok := event.Attendee(userID); !ok {
+			return nil, model.ErrAttendeeNotFound
+		}
+
+		attendees := make([]model.Attendee, len(event.Attendees))
+		for i, attendee := range event.Attendees {
+			if attendee.UserID == userID {
+				attendee.Status = status
+			}
+			attendees[i] = attendee
+		}
+
+		return attendees, nil
+	})
+	if err != nil {
+		s.logError("error while responding to invitation", err, "user_id", userID, "event_id", id)
+		return model.Event{}, err
+	}
+
+	s.publish(bus.ChangeUpdated, event.UserId, id)
+	return event, nil
+}
+
+// Исключение участника attendeeID из события id. Исключить любого участника может организатор,
+// а участник - только себя, отказавшись от события
+func (s *eventService) RemoveAttendee(userID, id, attendeeID int) error {
+	event, err := s.repo.UpdateAttendees(id, func(event model.Event) ([]model.Attendee, error) {
+		if event.UserId != userID && attendeeID != userID {
+			return nil, model.ErrForbidden
+		}
+
+		if _, ok := event.Attendee(attendeeID); !ok {
+			return nil, model.ErrAttendeeNotFound
+		}
+
+		attendees := []model.Attendee{}
+		for _, attendee := range event.Attendees {
+			if attendee.UserID != attendeeID {
+				attendees = append(attendees, attendee)
+			}
+		}
+
+		return attendees, nil
+	})
+	if err != nil {
+		s.logError("error while removing attendee", err, "user_id", userID, "event_id", id, "attendee_id", attendeeID)
+		return err
+	}
+
+	s.publish(bus.ChangeUpdated, event.UserId, id, attendeeID)
+	return nil
+}
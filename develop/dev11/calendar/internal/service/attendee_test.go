@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+)
+
+// Статусы участников события по их ID
+func attendeeStatuses(event model.Event) map[int]string {
+	statuses := map[int]string{}
+	for _, attendee := range event.Attendees {
+		statuses[attendee.UserID] = attendee.Status
+	}
+	return statuses
+}
+
+// Событие организатора 1 с приглашенными пользователями 2 и 3 в сервисе s
+func invitedEvent(t *testing.T, s IEventService) int {
+	t.Helper()
+
+	id, _, err := s.Insert(1, InsertEventDTO{Date: "2023-05-01", Description: "meeting"})
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+	if _, err := s.InviteAttendees(1, id, []int{2, 3}); err != nil {
+		t.Fatalf("inviting attendees: %v", err)
+	}
+
+	return id
+}
+
+func Test_eventService_InviteAttendees(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+	id := invitedEvent(t, s)
+
+	if _, err := s.RespondToInvitation(2, id, model.RSVPAccepted); err != nil {
+		t.Fatalf("responding: %v", err)
+	}
+
+	// Повторное приглашение сохраняет ответы и не дублирует участников
+	event, err := s.InviteAttendees(1, id, []int{2, 4, 4})
+	if err != nil {
+		t.Fatalf("inviting again: %v", err)
+	}
+	want := map[int]string{2: model.RSVPAccepted, 3: model.RSVPNeedsAction, 4: model.RSVPNeedsAction}
+	if got := attendeeStatuses(event); !reflect.DeepEqual(got, want) {
+		t.Errorf("attendees = %v, want %v", got, want)
+	}
+
+	// Приглашать может только организатор, и только других пользователей
+	if _, err := s.InviteAttendees(2, id, []int{5}); !errors.Is(err, model.ErrForbidden) {
+		t.Errorf("invite by attendee error = %v, want ErrForbidden", err)
+	}
+	if _, err := s.InviteAttendees(1, id, []int{1}); validationField(err) != "user_ids" {
+		t.Errorf("inviting organizer error = %v, want validation error of user_ids", err)
+	}
+	if _, err := s.InviteAttendees(1, 100, []int{2}); !errors.Is(err, model.ErrEventNotFound) {
+		t.Errorf("invite to missing event error = %v, want ErrEventNotFound", err)
+	}
+}
+
+func Test_eventService_RespondToInvitation(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+	id := invitedEvent(t, s)
+
+	event, err := s.RespondToInvitation(3, id, model.RSVPDeclined)
+	if err != nil {
+		t.Fatalf("responding: %v", err)
+	}
+	want := map[int]string{2: model.RSVPNeedsAction, 3: model.RSVPDeclined}
+	if got := attendeeStatuses(event); !reflect.DeepEqual(got, want) {
+		t.Errorf("attendees = %v, want %v", got, want)
+	}
+
+	// Отвечать могут только приглашенные
+	for _, userID := range []int{1, 4} {
+		if _, err := s.RespondToInvitation(userID, id, model.RSVPAccepted); !errors.Is(err, model.ErrAttendeeNotFound) {
+			t.Errorf("response of user %d error = %v, want ErrAttendeeNotFound", userID, err)
+		}
+	}
+}
+
+func Test_eventService_RemoveAttendee(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+	id := invitedEvent(t, s)
+
+	// Участник не может исключить другого участника
+	if err := s.RemoveAttendee(2, id, 3); !errors.Is(err, model.ErrForbidden) {
+		t.Errorf("removing other attendee error = %v, want ErrForbidden", err)
+	}
+
+	// Участник может отказаться сам, организатор может исключить любого
+	if err := s.RemoveAttendee(2, id, 2); err != nil {
+		t.Fatalf("leaving event: %v", err)
+	}
+	if err := s.RemoveAttendee(1, id, 3); err != nil {
+		t.Fatalf("removing attendee: %v", err)
+	}
+	if err := s.RemoveAttendee(1, id, 3); !errors.Is(err, model.ErrAttendeeNotFound) {
+		t.Errorf("removing missing attendee error = %v, want ErrAttendeeNotFound", err)
+	}
+
+	// Исключенные участники больше не видят событие
+	if _, err := s.GetByID(2, id); !errors.Is(err, model.ErrEventNotFound) {
+		t.Errorf("event for removed attendee error = %v, want ErrEventNotFound", err)
+	}
+}
+
+func Test_eventService_organizerOnlyEditing(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+	id := invitedEvent(t, s)
+
+	// Участник видит событие
+	if _, err := s.GetByID(2, id); err != nil {
+		t.Fatalf("event for attendee error = %v", err)
+	}
+
+	// Изменять и удалять событие может только организатор
+	description := "hijacked"
+	edits := map[string]func() error{
+		"update": func() error {
+			_, err := s.Update(2, id, 0, UpdateEventDTO{Date: "2023-05-02", Description: description})
+			return err
+		},
+		"patch": func() error {
+			_, err := s.Patch(2, id, 0, PatchEventDTO{Description: &description})
+			return err
+		},
+		"remove": func() error { return s.Remove(2, id, 0) },
+	}
+	for name, edit := range edits {
+		if err := edit(); !errors.Is(err, model.ErrForbidden) {
+			t.Errorf("%s by attendee error = %v, want ErrForbidden", name, err)
+		}
+	}
+
+	if event, _ := s.GetByID(1, id); event.Description != "meeting" || event.Version != 2 {
+		t.Errorf("event after attendee edits = %+v", event)
+	}
+}
+
+func Test_eventService_publishAudience(t *testing.T) {
+	eventBus := bus.NewBus()
+	s := NewEventService(newTestRepository(t), eventBus, discardLogger, testMaxRangeDays)
+	id := invitedEvent(t, s)
+
+	sub, err := eventBus.Subscribe(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// Ответ участника, исключение участника и удаление события
+	if _, err := s.RespondToInvitation(2, id, model.RSVPAccepted); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveAttendee(1, id, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(1, id, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Изменения видны организатору, участникам и исключенному участнику
+	want := [][]int{{1, 2, 3}, {1, 2, 3}, {1, 2}}
+	for i, audience := range want {
+		change := <-sub.Changes()
+		visible := []int{}
+		for userID := 1; userID <= 4; userID++ {
+			if change.VisibleTo(userID) {
+				visible = append(visible, userID)
+			}
+		}
+		sort.Ints(visible)
+
+		if !reflect.DeepEqual(visible, audience) {
+			t.Errorf("change %d (%s) visible to %v, want %v", i, change.Type, visible, audience)
+		}
+	}
+}
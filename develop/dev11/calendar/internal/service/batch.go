@@ -0,0 +1,131 @@
+package service
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/repository"
+	"fmt"
+)
+
+// Результат операции пакета: ID и новая версия события либо ошибка операции
+type BatchResult struct {
+	ID      int
+	Version int
+	Err     error
+}
+
+// Ошибка операции пакета с номером Index, из-за которой атомарный пакет не применен
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Виды изменений репозитория и изменений в шине по видам операций пакета
+var (
+	batchMutations = map[string]repository.MutationKind{
+		BatchInsert: repository.MutationInsert,
+		BatchUpdate: repository.MutationUpdate,
+		BatchDelete: repository.MutationRemove,
+	}
+	batchChanges = map[string]string{
+		BatchInsert: bus.ChangeCreated,
+		BatchUpdate: bus.ChangeUpdated,
+		BatchDelete: bus.ChangeDeleted,
+	}
+)
+
+// Применение пакета операций ops пользователя userID одним изменением репозитория.
+// Атомарный пакет применяется целиком либо не применяется вовсе: тогда возвращается *BatchError
+// с первой неудавшейся операцией. Иначе применяются все операции без ошибок, а ошибки остальных
+// возвращаются в их результатах
+func (s *eventService) Batch(userID int, ops []BatchOperationDTO, atomic bool) ([]BatchResult, error) {
+	results := make([]BatchResult, len(ops))
+
+	// Изменения репозитория и номера операций, из которых они получены
+	mutations := make([]repository.Mutation, 0, len(ops))
+	indexes := make([]int, 0, len(ops))
+	for i, op := range ops {
+		mutation, err := s.mutation(userID, op)
+		if err != nil {
+			if atomic {
+				return nil, &BatchError{Index: i, Err: err}
+			}
+			results[i].Err = err
+			continue
+		}
+
+		mutations = append(mutations, mutation)
+		indexes = append(indexes, i)
+	}
+
+	applied, err := s.repo.Apply(mutations, atomic)
+	if err != nil {
+		s.logError("error while applying batch", err, "user_id", userID, "operations", len(ops))
+		return nil, err
+	}
+
+	for j, result := range applied {
+		i := indexes[j]
+		if result.Err != nil {
+			if atomic {
+				return nil, &BatchError{Index: i, Err: result.Err}
+			}
+			results[i].Err = result.Err
+			continue
+		}
+
+		results[i].ID, results[i].Version = result.ID, result.Version
+	}
+
+	for i, op := range ops {
+		if results[i].Err == nil {
+			s.publish(batchChanges[op.Op], userID, results[i].ID)
+		}
+	}
+
+	return results, nil
+}
+
+// Изменение репозитория по операции пакета op пользователя userID
+func (s *eventService) mutation(userID int, op BatchOperationDTO) (repository.Mutation, error) {
+	kind, ok := batchMutations[op.Op]
+	if !ok {
+		return repository.Mutation{}, model.NewValidationError("op", "should be one of insert, update or delete")
+	}
+
+	if op.Op != BatchInsert {
+		if _, err := s.getOwned(userID, op.ID); err != nil {
+			return repository.Mutation{}, err
+		}
+	}
+
+	mutation := repository.Mutation{Kind: kind, ID: op.ID, Version: op.Version}
+	if op.Op == BatchDelete {
+		return mutation, nil
+	}
+
+	mutation.Event = model.Event{
+		UserId:      userID,
+		Date:        op.Event.Date,
+		Start:       op.Event.Start,
+		End:         op.Event.End,
+		TimeZone:    op.Event.TimeZone,
+		Recurrence:  op.Event.Recurrence,
+		Reminders:   op.Event.Reminders,
+		Description: op.Event.Description,
+	}
+
+	if err := mutation.Event.FillDate(); err != nil {
+		return repository.Mutation{}, err
+	}
+
+	return mutation, nil
+}
@@ -1,21 +1,83 @@
 package service
 
-// DTO для добавления
+import "dev11/calendar/internal/model"
+
+// DTO для добавления. Владельцем события становится аутентифицированный пользователь.
+// Событие на весь день задается полем date, событие со временем - полями start и end в RFC 3339.
+// Заданное правило recurrence делает событие серией.
+// RejectOnConflict запрещает добавление, если время события у владельца уже занято;
+// без него пересечения возвращаются предупреждением
 type InsertEventDTO struct {
-	UserId      int    `json:"user_id"`
-	Date        string `json:"date"`
-	Description string `json:"description"`
+	Date        string            `json:"date"`
+	Start       string            `json:"start"`
+	End         string            `json:"end"`
+	TimeZone    string            `json:"timezone"`
+	Recurrence  *model.Recurrence `json:"recurrence"`
+	Reminders   []int             `json:"reminders"`
+	Description string            `json:"description"`
+	// Режим запроса, а не поле события
+	RejectOnConflict bool `json:"-"`
 }
 
-// DTO для обновления
+// DTO для обновления.
+// Заданная дата occurrence означает изменение только этого повторения серии.
+// Ненулевая version - ожидаемая текущая версия события.
+// RejectOnConflict запрещает изменение, если новое время занято у организатора или участников;
+// без него пересечения возвращаются предупреждением
 type UpdateEventDTO struct {
-	ID          int    `json:"id"`
-	UserId      int    `json:"user_id"`
-	Date        string `json:"date"`
-	Description string `json:"description"`
+	ID          int               `json:"id"`
+	Version     int               `json:"version"`
+	Occurrence  string            `json:"occurrence"`
+	Date        string            `json:"date"`
+	Start       string            `json:"start"`
+	End         string            `json:"end"`
+	TimeZone    string            `json:"timezone"`
+	Recurrence  *model.Recurrence `json:"recurrence"`
+	Reminders   []int             `json:"reminders"`
+	Description string            `json:"description"`
+	// Режим запроса, а не поле события
+	RejectOnConflict bool `json:"-"`
 }
 
-// DTO для удаления
+// DTO для удаления.
+// Заданная дата occurrence означает удаление только этого повторения серии.
+// Ненулевая version - ожидаемая текущая версия события
 type RemoveEventDTO struct {
-	ID int `json:"id"`
+	ID         int    `json:"id"`
+	Version    int    `json:"version"`
+	Occurrence string `json:"occurrence"`
+}
+
+// DTO для частичного обновления. Незаданные поля остаются прежними.
+// Заданная без start дата делает событие событием на весь день.
+// Ненулевая version - ожидаемая текущая версия события.
+// RejectOnConflict запрещает изменение, если новое время занято у организатора или участников;
+// без него пересечения возвращаются предупреждением
+type PatchEventDTO struct {
+	Version     int               `json:"version"`
+	Date        *string           `json:"date"`
+	Start       *string           `json:"start"`
+	End         *string           `json:"end"`
+	TimeZone    *string           `json:"timezone"`
+	Recurrence  *model.Recurrence `json:"recurrence"`
+	Reminders   *[]int            `json:"reminders"`
+	Description *string           `json:"description"`
+	// Режим запроса, а не поле события
+	RejectOnConflict bool `json:"-"`
+}
+
+// Виды операций пакета
+const (
+	BatchInsert = "insert"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// DTO операции пакета изменений: вставка события Event, замена события ID событием Event
+// или удаление события ID. Ненулевая version - ожидаемая текущая версия события
+type BatchOperationDTO struct {
+	Op      string
+	ID      int
+	Version int
+	Event   UpdateEventDTO
 }
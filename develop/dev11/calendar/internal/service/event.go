@@ -1,22 +1,36 @@
 package service
 
 import (
+	"dev11/calendar/internal/bus"
 	"dev11/calendar/internal/model"
 	"dev11/calendar/internal/repository"
+	"dev11/calendar/pkg/logger"
 	"errors"
-	"log"
+	"fmt"
 	"time"
 )
 
-// Сервис событий
+// Сервис событий.
+// Все операции выполняются от имени пользователя userID: чтение ограничено событиями, которые он
+// организует или на которые приглашен, а изменение событий других организаторов запрещено.
+// Изменения выполняются, только если текущая версия события (для повторения - серии) совпадает
+// с ожидаемой version; нулевая version отключает проверку.
+// Каждое успешное изменение публикуется в шину изменений.
+// Периоды выборок не шире maxRangeDays дней
 type eventService struct {
-	repo repository.IEventRepository
+	repo         repository.IEventRepository
+	bus          bus.IBus
+	logger       logger.ILogger
+	maxRangeDays int
 }
 
 // Конструктор сервиса событий
-func NewEventService(repo repository.IEventRepository) IEventService {
+func NewEventService(repo repository.IEventRepository, bus bus.IBus, logger logger.ILogger, maxRangeDays int) IEventService {
 	return &eventService{
-		repo: repo,
+		repo:         repo,
+		bus:          bus,
+		logger:       logger,
+		maxRangeDays: maxRangeDays,
 	}
 }
 
@@ -25,82 +39,383 @@ func (s *eventService) SaveEvents() error {
 	return s.repo.SaveEvents()
 }
 
-// Добавление события
-func (s *eventService) Insert(dto InsertEventDTO) int {
+// Количество изменений, не сохраненных в снимок хранилища
+func (s *eventService) PendingChanges() int {
+	return s.repo.PendingChanges()
+}
+
+// Добавление события пользователю userID. Возвращает пересечения с занятостью владельца
+func (s *eventService) Insert(userID int, dto InsertEventDTO) (int, []Conflict, error) {
+	event := model.Event{
+		UserId:      userID,
+		Date:        dto.Date,
+		Start:       dto.Start,
+		End:         dto.End,
+		TimeZone:    dto.TimeZone,
+		Recurrence:  dto.Recurrence,
+		Reminders:   dto.Reminders,
+		Description: dto.Description,
+	}
+
+	if err := event.FillDate(); err != nil {
+		return 0, nil, err
+	}
+
+	var conflicts []Conflict
+	id, err := s.repo.Insert(event, s.conflictCheck(dto.RejectOnConflict, nil, &conflicts))
+	if err != nil {
+		s.logError("error while inserting event", err, "user_id", userID)
+		return 0, nil, err
+	}
+
+	s.publish(bus.ChangeCreated, userID, id)
+	return id, conflicts, nil
+}
+
+// Обновление события (для серии - всех ее повторений).
+// Возвращает пересечения с занятостью организатора и участников
+func (s *eventService) Update(userID, id, version int, dto UpdateEventDTO) ([]Conflict, error) {
+	if _, err := s.getOwned(userID, id); err != nil {
+		return nil, err
+	}
+
 	event := model.Event{
-		UserId:      dto.UserId,
+		UserId:      userID,
 		Date:        dto.Date,
+		Start:       dto.Start,
+		End:         dto.End,
+		TimeZone:    dto.TimeZone,
+		Recurrence:  dto.Recurrence,
+		Reminders:   dto.Reminders,
 		Description: dto.Description,
 	}
 
-	return s.repo.Insert(event)
+	if err := event.FillDate(); err != nil {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	err := s.repo.Update(id, version, event, s.conflictCheck(dto.RejectOnConflict, sameEvent(id), &conflicts))
+	if err != nil {
+		s.logError("error while updating event", err, "user_id", userID, "event_id", id)
+		return nil, err
+	}
+
+	s.publish(bus.ChangeUpdated, userID, id)
+	return conflicts, nil
 }
 
-// Обновление события
-func (s *eventService) Update(id int, dto UpdateEventDTO) error {
+// Обновление отдельного повторения date серии id. Возвращает ID события повторения
+// и пересечения с занятостью организатора и участников
+func (s *eventService) UpdateOccurrence(userID, id int, date string, version int, dto UpdateEventDTO) (int, []Conflict, error) {
+	if _, err := s.getOwned(userID, id); err != nil {
+		return 0, nil, err
+	}
+
 	event := model.Event{
-		UserId:      dto.UserId,
+		UserId:      userID,
 		Date:        dto.Date,
+		Start:       dto.Start,
+		End:         dto.End,
+		TimeZone:    dto.TimeZone,
+		Reminders:   dto.Reminders,
 		Description: dto.Description,
 	}
 
-	err := s.repo.Update(id, event)
+	if err := event.FillDate(); err != nil {
+		return 0, nil, err
+	}
+
+	var conflicts []Conflict
+	check := s.conflictCheck(dto.RejectOnConflict, sameOccurrence(id, date), &conflicts)
+	overrideID, err := s.repo.DetachOccurrence(id, date, version, event, check)
 	if err != nil {
-		log.Printf("error while updating event: %v", err)
+		s.logError("error while updating occurrence", err, "user_id", userID, "event_id", id, "occurrence", date)
+		return 0, nil, err
 	}
-	return err
+
+	s.publishOccurrence(bus.ChangeUpdated, userID, id, date, overrideID)
+	return overrideID, conflicts, nil
+}
+
+// Частичное обновление события. Возвращает пересечения с занятостью организатора и участников
+func (s *eventService) Patch(userID, id, version int, dto PatchEventDTO) ([]Conflict, error) {
+	event, err := s.getOwned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Наложение заданных полей на текущее состояние события
+	if dto.Date != nil && dto.Start == nil {
+		event.Date = *dto.Date
+		event.Start, event.End = "", ""
+	}
+	if dto.Start != nil {
+		event.Start = *dto.Start
+	}
+	if dto.End != nil {
+		event.End = *dto.End
+	}
+	if dto.TimeZone != nil {
+		event.TimeZone = *dto.TimeZone
+	}
+	if dto.Recurrence != nil {
+		event.Recurrence = dto.Recurrence
+	}
+	if dto.Reminders != nil {
+		event.Reminders = *dto.Reminders
+	}
+	if dto.Description != nil {
+		event.Description = *dto.Description
+	}
+
+	// Проверка согласованности итогового интервала
+	if _, _, err := event.Interval(time.UTC); err != nil {
+		return nil, err
+	}
+	if err := event.FillDate(); err != nil {
+		return nil, err
+	}
+
+	// Изменение построено на прочитанном состоянии, поэтому без ожидаемой версии записывается
+	// только поверх него: одновременное изменение между чтением и записью не затирается
+	if version == 0 {
+		version = event.Version
+	}
+
+	var conflicts []Conflict
+	err = s.repo.Update(id, version, event, s.conflictCheck(dto.RejectOnConflict, sameEvent(id), &conflicts))
+	if err != nil {
+		s.logError("error while patching event", err, "user_id", userID, "event_id", id)
+		return nil, err
+	}
+
+	s.publish(bus.ChangeUpdated, userID, id)
+	return conflicts, nil
 }
 
 // Удаление события
-func (s *eventService) Remove(id int) error {
-	err := s.repo.Remove(id)
+func (s *eventService) Remove(userID, id, version int) error {
+	if _, err := s.getOwned(userID, id); err != nil {
+		return err
+	}
+
+	err := s.repo.Remove(id, version)
 	if err != nil {
-		log.Printf("error while removing event: %v", err)
+		s.logError("error while removing event", err, "user_id", userID, "event_id", id)
+		return err
 	}
-	return err
+
+	s.publish(bus.ChangeDeleted, userID, id)
+	return nil
 }
 
-// Получение событий за дату date
-func (s *eventService) GetForDay(date string) ([]model.Event, error) {
-	dateAsTime, err := time.Parse(model.DateLayout, date)
+// Удаление отдельного повторения date серии id
+func (s *eventService) RemoveOccurrence(userID, id int, date string, version int) error {
+	if _, err := s.getOwned(userID, id); err != nil {
+		return err
+	}
+
+	err := s.repo.ExcludeOccurrence(id, date, version)
 	if err != nil {
-		return []model.Event{}, errors.New("incorrect date format")
+		s.logError("error while removing occurrence", err, "user_id", userID, "event_id", id, "occurrence", date)
+		return err
 	}
 
-	events, err := s.repo.GetForDay(dateAsTime)
+	s.publishOccurrence(bus.ChangeDeleted, userID, id, date, 0)
+	return nil
+}
+
+// Получение события по ID. Событие, на которое пользователь не приглашен, для него не существует
+func (s *eventService) GetByID(userID, id int) (model.Event, error) {
+	event, err := s.repo.GetByID(id)
 	if err != nil {
-		log.Printf("error while getting events for day: %v", err)
+		return model.Event{}, err
+	}
+
+	if !event.VisibleTo(userID) {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	return event, nil
+}
+
+// Получение страницы событий с датами от from до to с границами bounds ("[]", "[)", "(]", "()").
+// Даты отсчитываются в часовом поясе tz
+func (s *eventService) GetRange(userID int, from, to, bounds, tz string, opts ListOptions) (EventPage, error) {
+	rng, err := s.parseRange(from, to, bounds, tz)
+	if err != nil {
+		return EventPage{}, err
+	}
+
+	return s.list(userID, rng, opts, "error while getting events for range")
+}
+
+// Получение страницы событий за дату date в часовом поясе tz
+func (s *eventService) GetForDay(userID int, date, tz string, opts ListOptions) (EventPage, error) {
+	dateAsTime, err := parseDate("date", date, tz)
+	if err != nil {
+		return EventPage{}, err
+	}
+
+	return s.list(userID, model.DayRange(dateAsTime), opts, "error while getting events for day")
+}
+
+// Получение страницы событий за неделю, в которой имеется дата date, в часовом поясе tz
+func (s *eventService) GetForWeek(userID int, date, tz string, opts ListOptions) (EventPage, error) {
+	dateAsTime, err := parseDate("date", date, tz)
+	if err != nil {
+		return EventPage{}, err
+	}
+
+	return s.list(userID, model.WeekRange(dateAsTime), opts, "error while getting events for week")
+}
+
+// Получение страницы событий за месяц, в котором имеется дата date, в часовом поясе tz
+func (s *eventService) GetForMonth(userID int, date, tz string, opts ListOptions) (EventPage, error) {
+	dateAsTime, err := parseDate("date", date, tz)
+	if err != nil {
+		return EventPage{}, err
+	}
+
+	return s.list(userID, model.MonthRange(dateAsTime), opts, "error while getting events for month")
+}
+
+// Получение страницы событий пользователя userID за период rng. При ошибке репозитория пишется msg
+func (s *eventService) list(userID int, rng model.Range, opts ListOptions, msg string) (EventPage, error) {
+	events, err := s.repo.GetRangeForUser(userID, rng)
+	if err != nil {
+		s.logError(msg, err, "user_id", userID)
+		return EventPage{}, err
+	}
+
+	return paginate(events, opts)
+}
+
+// Получение события id с проверкой, что оно принадлежит пользователю userID
+func (s *eventService) getOwned(userID, id int) (model.Event, error) {
+	event, err := s.repo.GetByID(id)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	if event.UserId != userID {
+		return model.Event{}, model.ErrForbidden
+	}
+
+	return event, nil
+}
+
+// Запись ошибки операции msg. Ошибки, вызванные самим запросом (типизированные ошибки, кроме внутренних:
+// отсутствие события или повторения, конфликт версий, запрет, некорректные параметры),
+// пишутся предупреждением, остальные - ошибкой
+func (s *eventService) logError(msg string, err error, keyvals ...any) {
+	keyvals = append(keyvals, "err", err)
+
+	var typed *model.Error
+	if errors.As(err, &typed) && typed.Kind != model.KindInternal {
+		s.logger.Warn(msg, keyvals...)
+		return
+	}
+
+	s.logger.Error(msg, keyvals...)
+}
+
+// Публикация изменения changeType события id организатора userID с его текущим состоянием.
+// Изменение видно участникам события и пользователям removedIDs, исключенным из него этим изменением
+func (s *eventService) publish(changeType string, userID, id int, removedIDs ...int) {
+	change := bus.Change{Type: changeType, EventID: id, UserID: userID}
+	if event, err := s.repo.GetByID(id); err == nil {
+		change.Event = &event
+		change.AttendeeIDs = attendeeIDs(event)
+	} else if event, err := s.repo.GetDeletedByID(id); err == nil {
+		// Удаленное событие не передается, но его участники узнают об удалении
+		change.AttendeeIDs = attendeeIDs(event)
+	}
+	change.AttendeeIDs = append(change.AttendeeIDs, removedIDs...)
+
+	s.bus.Publish(change)
+}
+
+// Публикация изменения changeType повторения date серии id организатора userID.
+// Ненулевой overrideID - ID события, отдельно измененного повторения
+func (s *eventService) publishOccurrence(changeType string, userID, id int, date string, overrideID int) {
+	change := bus.Change{Type: changeType, EventID: id, UserID: userID, Occurrence: date}
+	if series, err := s.repo.GetByID(id); err == nil {
+		change.AttendeeIDs = attendeeIDs(series)
+	}
+	if overrideID != 0 {
+		if event, err := s.repo.GetByID(overrideID); err == nil {
+			change.Event = &event
+		}
+	}
+
+	s.bus.Publish(change)
+}
+
+// ID участников события event
+func attendeeIDs(event model.Event) []int {
+	ids := make([]int, 0, len(event.Attendees))
+	for _, attendee := range event.Attendees {
+		ids = append(ids, attendee.UserID)
+	}
+
+	return ids
+}
+
+// События пользователя userID из events
+func ownedBy(events []model.Event, userID int) []model.Event {
+	owned := []model.Event{}
+	for _, event := range events {
+		if event.UserId == userID {
+			owned = append(owned, event)
+		}
+	}
+
+	return owned
+}
+
+// Парсинг периода целых дней с даты from по дату to с границами bounds в часовом поясе tz
+// с проверкой, что период не шире maxRangeDays дней
+func (s *eventService) parseRange(from, to, bounds, tz string) (model.Range, error) {
+	rng, err := parseRange(from, to, bounds, tz)
+	if err != nil {
+		return model.Range{}, err
+	}
+
+	if rng.To.After(rng.From.AddDate(0, 0, s.maxRangeDays)) {
+		return model.Range{}, model.NewValidationError("to", fmt.Sprintf("should be at most %d days after from", s.maxRangeDays))
 	}
 
-	return events, err
+	return rng, nil
 }
 
-// Получение событий за неделю, в которой имеется дата date
-func (s *eventService) GetForWeek(date string) ([]model.Event, error) {
-	dateAsTime, err := time.Parse(model.DateLayout, date)
+// Парсинг периода целых дней с даты from по дату to с границами bounds в часовом поясе tz
+func parseRange(from, to, bounds, tz string) (model.Range, error) {
+	fromAsTime, err := parseDate("from", from, tz)
 	if err != nil {
-		return []model.Event{}, errors.New("incorrect date format")
+		return model.Range{}, err
 	}
 
-	events, err := s.repo.GetForWeek(dateAsTime)
+	toAsTime, err := parseDate("to", to, tz)
 	if err != nil {
-		log.Printf("error while getting events for week: %v", err)
+		return model.Range{}, err
 	}
 
-	return events, err
+	return model.NewDayRange(fromAsTime, toAsTime, bounds)
 }
 
-// Получение событий за месяц, в котором имеется дата date
-func (s *eventService) GetForMonth(date string) ([]model.Event, error) {
-	dateAsTime, err := time.Parse(model.DateLayout, date)
+// Парсинг даты параметра field в часовом поясе tz. Пустой пояс означает UTC
+func parseDate(field, date, tz string) (time.Time, error) {
+	loc, err := time.LoadLocation(tz)
 	if err != nil {
-		return []model.Event{}, errors.New("incorrect date format")
+		return time.Time{}, model.NewValidationError("tz", "should be an IANA timezone name")
 	}
 
-	events, err := s.repo.GetForMonth(dateAsTime)
+	dateAsTime, err := time.ParseInLocation(model.DateLayout, date, loc)
 	if err != nil {
-		log.Printf("error while getting events for month: %v", err)
+		return time.Time{}, model.NewValidationError(field, "should be in format 2006-01-02")
 	}
 
-	return events, err
+	return dateAsTime, nil
 }
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/journal"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/repository"
+	"dev11/calendar/internal/storage"
+	"dev11/calendar/pkg/logger"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Логгер, отбрасывающий записи
+var discardLogger = logger.New(io.Discard, logger.LevelDebug)
+
+// Наибольшая ширина периода выборок тестового сервиса в днях
+const testMaxRangeDays = 366
+
+// Репозиторий с файловым хранилищем во временной директории
+func newTestRepository(t *testing.T) repository.IEventRepository {
+	t.Helper()
+	dir := t.TempDir()
+
+	store, err := storage.New(storage.TypeFile, filepath.Join(dir, "data.json"))
+	if err != nil {
+		t.Fatalf("opening storage: %v", err)
+	}
+
+	eventJournal, err := journal.NewJournal(filepath.Join(dir, "journal.log"), discardLogger)
+	if err != nil {
+		t.Fatalf("opening journal: %v", err)
+	}
+	t.Cleanup(func() { eventJournal.Close() })
+
+	repo, err := repository.NewEventRepository(store, eventJournal, discardLogger)
+	if err != nil {
+		t.Fatalf("opening repository: %v", err)
+	}
+
+	return repo
+}
+
+// Поле ошибки валидации err или пустая строка для других ошибок
+func validationField(err error) string {
+	var modelErr *model.Error
+	if !errors.As(err, &modelErr) || modelErr.Kind != model.KindValidation || len(modelErr.Fields) == 0 {
+		return ""
+	}
+	return modelErr.Fields[0].Field
+}
+
+func Test_parseRange(t *testing.T) {
+	tests := []struct {
+		name             string
+		from, to, bounds string
+		tz               string
+		wantFrom, wantTo string
+		wantErrField     string
+	}{
+		{"inclusive in UTC", "2024-01-01", "2024-01-03", "[]", "", "2024-01-01T00:00:00Z", "2024-01-04T00:00:00Z", ""},
+		{"exclusive in UTC", "2024-01-01", "2024-01-03", "()", "", "2024-01-02T00:00:00Z", "2024-01-03T00:00:00Z", ""},
+		{"inclusive in Moscow", "2024-01-01", "2024-01-01", "[]", "Europe/Moscow", "2023-12-31T21:00:00Z", "2024-01-01T21:00:00Z", ""},
+		{"half-open in New York", "2024-03-10", "2024-03-11", "[)", "America/New_York", "2024-03-10T05:00:00Z", "2024-03-11T04:00:00Z", ""},
+		{"unknown time zone", "2024-01-01", "2024-01-03", "[]", "Mars/Olympus", "", "", "tz"},
+		{"bad date", "2024-01-01", "01.03.2024", "[]", "", "", "", "to"},
+		{"bad bounds", "2024-01-01", "2024-01-03", "{}", "", "", "", "bounds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng, err := parseRange(tt.from, tt.to, tt.bounds, tt.tz)
+			if tt.wantErrField != "" {
+				if field := validationField(err); field != tt.wantErrField {
+					t.Errorf("parseRange() error = %v, want validation error of %q", err, tt.wantErrField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRange() error = %v", err)
+			}
+
+			from, _ := time.Parse(time.RFC3339, tt.wantFrom)
+			to, _ := time.Parse(time.RFC3339, tt.wantTo)
+			if !rng.From.Equal(from) || !rng.To.Equal(to) || rng.ExcludeFrom || !rng.ExcludeTo {
+				t.Errorf("parseRange() = [%s, %s) excludeFrom=%v excludeTo=%v, want [%s, %s)",
+					rng.From.UTC().Format(time.RFC3339), rng.To.UTC().Format(time.RFC3339), rng.ExcludeFrom, rng.ExcludeTo, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+// Репозиторий, выполняющий before перед ближайшим обновлением - одновременное изменение
+// между чтением и записью события
+type interleavingRepo struct {
+	repository.IEventRepository
+	before func()
+}
+
+func (r *interleavingRepo) Update(id, version int, event model.Event, check repository.Check) error {
+	if before := r.before; before != nil {
+		r.before = nil
+		before()
+	}
+	return r.IEventRepository.Update(id, version, event, check)
+}
+
+func Test_eventService_PatchConcurrent(t *testing.T) {
+	repo := &interleavingRepo{IEventRepository: newTestRepository(t)}
+	s := NewEventService(repo, bus.NewBus(), discardLogger, testMaxRangeDays)
+
+	id, _, err := s.Insert(1, InsertEventDTO{Date: "2023-05-01", Description: "start"})
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+
+	// Пока первый клиент меняет описание, второй успевает изменить напоминания
+	repo.before = func() {
+		if _, err := s.Patch(1, id, 0, PatchEventDTO{Reminders: &[]int{15}}); err != nil {
+			t.Errorf("concurrent patch: %v", err)
+		}
+	}
+
+	description := "changed"
+	_, err = s.Patch(1, id, 0, PatchEventDTO{Description: &description})
+	if !errors.Is(err, model.ErrVersionConflict) {
+		t.Fatalf("Patch() over a stale read error = %v, want ErrVersionConflict", err)
+	}
+
+	// Напоминания второго клиента не затерты устаревшим состоянием
+	event, err := s.GetByID(1, id)
+	if err != nil {
+		t.Fatalf("getting event: %v", err)
+	}
+	if event.Description != "start" || !reflect.DeepEqual(event.Reminders, []int{15}) {
+		t.Errorf("event after concurrent patches = %+v", event)
+	}
+
+	// Повтор без конфликта применяется поверх нового состояния
+	if _, err := s.Patch(1, id, 0, PatchEventDTO{Description: &description}); err != nil {
+		t.Fatalf("retrying patch: %v", err)
+	}
+	if event, _ = s.GetByID(1, id); event.Description != "changed" || !reflect.DeepEqual(event.Reminders, []int{15}) {
+		t.Errorf("event after retry = %+v", event)
+	}
+}
+
+func Test_eventService_rangeLimit(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, 7)
+
+	tests := []struct {
+		name     string
+		from, to string
+		bounds   string
+		wantErr  bool
+	}{
+		{"seven whole days", "2023-05-01", "2023-05-07", "[]", false},
+		{"excluded end day", "2023-05-01", "2023-05-08", "[)", false},
+		{"eight days", "2023-05-01", "2023-05-08", "[]", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.GetRange(1, tt.from, tt.to, tt.bounds, "", ListOptions{})
+			if tt.wantErr && validationField(err) != "to" {
+				t.Errorf("GetRange() error = %v, want validation error of to", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("GetRange() error = %v", err)
+			}
+		})
+	}
+
+	if _, err := s.FreeBusy([]int{1}, "2023-05-01", "2023-06-01", "[]", ""); validationField(err) != "to" {
+		t.Errorf("FreeBusy() error = %v, want validation error of to", err)
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/recurrence"
+	"dev11/calendar/internal/repository"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Период от начала серии, на котором ее повторения проверяются на пересечение с занятостью участников
+const conflictHorizonYears = 1
+
+// Занятость пользователя за период: объединенные интервалы его событий
+type UserBusy struct {
+	UserID int              `json:"user_id"`
+	Busy   []model.Interval `json:"busy"`
+}
+
+// Занятость участника userID, пересекающаяся со временем события (в его пределах)
+type Conflict struct {
+	UserID int `json:"user_id"`
+	model.Interval
+}
+
+// Описание пересечения для человека
+func (c Conflict) String() string {
+	return fmt.Sprintf("user %d is busy from %s to %s", c.UserID,
+		c.Start.Format(model.TimeLayout), c.End.Format(model.TimeLayout))
+}
+
+// Занятость пользователей userIDs за период с датами от from до to с границами bounds
+// в часовом поясе tz. Занятость раскрывает только время событий, но не их содержание
+func (s *eventService) FreeBusy(userIDs []int, from, to, bounds, tz string) ([]UserBusy, error) {
+	rng, err := s.parseRange(from, to, bounds, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]UserBusy, 0, len(userIDs))
+	for _, userID := range userIDs {
+		busy, err := s.busy(s.repo, userID, rng, nil)
+		if err != nil {
+			s.logError("error while getting free/busy", err, "user_id", userID)
+			return nil, err
+		}
+
+		result = append(result, UserBusy{UserID: userID, Busy: busy})
+	}
+
+	return result, nil
+}
+
+// Первый интервал длительностью duration в периоде с датами от from до to с границами bounds
+// в часовом поясе tz, в который свободны все пользователи userIDs
+func (s *eventService) FindFreeSlot(userIDs []int, from, to, bounds, tz string, duration time.Duration) (model.Interval, error) {
+	rng, err := s.parseRange(from, to, bounds, tz)
+	if err != nil {
+		return model.Interval{}, err
+	}
+
+	// Общая занятость пользователей
+	var all []model.Interval
+	for _, userID := range userIDs {
+		busy, err := s.busy(s.repo, userID, rng, nil)
+		if err != nil {
+			s.logError("error while finding free slot", err, "user_id", userID)
+			return model.Interval{}, err
+		}
+
+		all = append(all, busy...)
+	}
+
+	// Свободный интервал начинается с начала периода или с окончания одного из интервалов занятости
+	start := rng.From
+	for _, busy := range mergeIntervals(all) {
+		if !start.Add(duration).After(busy.Start) {
+			break
+		}
+		if busy.End.After(start) {
+			start = busy.End
+		}
+	}
+
+	end := start.Add(duration)
+	if end.After(rng.To) {
+		return model.Interval{}, model.ErrFreeSlotNotFound
+	}
+
+	return model.Interval{Start: start, End: end}, nil
+}
+
+// Проверка занятости участников события под мьютексом репозитория, чтобы одновременно
+// записанные события не проходили проверку друг мимо друга. В режиме reject пересечение отменяет
+// запись, иначе найденные пересечения сохраняются в conflicts для предупреждения
+func (s *eventService) conflictCheck(reject bool, exclude func(model.Event) bool, conflicts *[]Conflict) repository.Check {
+	return func(event model.Event, events repository.IRangeReader) error {
+		found, err := s.checkConflicts(events, event, exclude)
+		if err != nil {
+			return err
+		}
+
+		if reject && len(found) > 0 {
+			return fmt.Errorf("%w: %s", model.ErrScheduleConflict, found[0])
+		}
+
+		*conflicts = found
+		return nil
+	}
+}
+
+// Пересечения времени события event с занятостью его организатора и участников, не отклонивших
+// приглашение. Серия проверяется на год от начала. События, для которых exclude возвращает true
+// (прежнее состояние изменяемого события), не учитываются
+func (s *eventService) checkConflicts(events repository.IRangeReader, event model.Event, exclude func(model.Event) bool) ([]Conflict, error) {
+	intervals, err := occupiedBy(event)
+	if err != nil || len(intervals) == 0 {
+		return nil, err
+	}
+
+	// Объединенные интервалы упорядочены, поэтому последний заканчивается позже всех
+	window := model.Range{From: intervals[0].Start, To: intervals[len(intervals)-1].End, ExcludeTo: true}
+
+	participants := []int{event.UserId}
+	for _, attendee := range event.Attendees {
+		if attendee.Status != model.RSVPDeclined {
+			participants = append(participants, attendee.UserID)
+		}
+	}
+
+	var conflicts []Conflict
+	for _, userID := range participants {
+		busy, err := s.busy(events, userID, window, exclude)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, interval := range overlapping(intervals, busy) {
+			conflicts = append(conflicts, Conflict{UserID: userID, Interval: interval})
+		}
+	}
+
+	return conflicts, nil
+}
+
+// Занятость пользователя userID за период rng по событиям из events без событий, для которых
+// exclude возвращает true. Интервалы обрезаются по границам периода и отсчитываются в его часовом поясе
+func (s *eventService) busy(events repository.IRangeReader, userID int, rng model.Range, exclude func(model.Event) bool) ([]model.Interval, error) {
+	userEvents, err := events.GetRangeForUser(userID, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := rng.From.Location()
+	intervals := make([]model.Interval, 0, len(userEvents))
+	for _, event := range userEvents {
+		if !event.BusyFor(userID) || (exclude != nil && exclude(event)) {
+			continue
+		}
+
+		start, end, err := event.Interval(loc)
+		if err != nil {
+			s.logger.Error("error while getting event's interval", "event_id", event.ID, "err", err)
+			continue
+		}
+
+		if start.Before(rng.From) {
+			start = rng.From
+		}
+		if end.After(rng.To) {
+			end = rng.To
+		}
+		if end.After(start) {
+			intervals = append(intervals, model.Interval{Start: start.In(loc), End: end.In(loc)})
+		}
+	}
+
+	return mergeIntervals(intervals), nil
+}
+
+// Интервалы, занимаемые событием event, в хронологическом порядке.
+// "Плавающее" событие на весь день отсчитывается в UTC
+func occupiedBy(event model.Event) ([]model.Interval, error) {
+	start, end, err := event.Interval(time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []model.Event{event}
+	if event.IsRecurring() {
+		horizon := model.Range{From: start, To: start.AddDate(conflictHorizonYears, 0, 0), ExcludeTo: true}
+		if occurrences, err = recurrence.Expand(event, horizon); err != nil {
+			return nil, err
+		}
+	}
+
+	intervals := make([]model.Interval, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		if start, end, err = occurrence.Interval(time.UTC); err != nil {
+			return nil, err
+		}
+		if end.After(start) {
+			intervals = append(intervals, model.Interval{Start: start, End: end})
+		}
+	}
+
+	return mergeIntervals(intervals), nil
+}
+
+// Упорядочивание интервалов по началу и объединение пересекающихся и смежных
+func mergeIntervals(intervals []model.Interval) []model.Interval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+
+	merged := []model.Interval{}
+	for _, interval := range intervals {
+		if last := len(merged) - 1; last >= 0 && !interval.Start.After(merged[last].End) {
+			if interval.End.After(merged[last].End) {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+
+		merged = append(merged, interval)
+	}
+
+	return merged
+}
+
+// Интервалы из b, пересекающиеся хотя бы с одним из интервалов a.
+// Оба списка упорядочены и не содержат пересекающихся интервалов
+func overlapping(a, b []model.Interval) []model.Interval {
+	var result []model.Interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if a[i].Start.Before(b[j].End) && b[j].Start.Before(a[i].End) {
+			result = append(result, b[j])
+			j++
+			continue
+		}
+
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return result
+}
+
+// Признак события id или одного из его повторений
+func sameEvent(id int) func(model.Event) bool {
+	return func(event model.Event) bool {
+		return event.ID == id || event.SeriesID == id
+	}
+}
+
+// Признак повторения date серии id, как развернутого, так и отдельно измененного
+func sameOccurrence(id int, date string) func(model.Event) bool {
+	return func(event model.Event) bool {
+		return sameEvent(id)(event) && event.RecurrenceID == date
+	}
+}
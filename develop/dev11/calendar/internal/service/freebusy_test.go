@@ -0,0 +1,203 @@
+package service
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Интервал [from, to) в часах от начала 2023-05-01 UTC
+func hours(from, to int) model.Interval {
+	base := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	return model.Interval{Start: base.Add(time.Duration(from) * time.Hour), End: base.Add(time.Duration(to) * time.Hour)}
+}
+
+func Test_mergeIntervals(t *testing.T) {
+	got := mergeIntervals([]model.Interval{hours(9, 10), hours(1, 3), hours(12, 14), hours(2, 4), hours(10, 11), hours(13, 14)})
+
+	want := []model.Interval{hours(1, 4), hours(9, 11), hours(12, 14)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeIntervals() = %v, want %v", got, want)
+	}
+}
+
+func Test_overlapping(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []model.Interval
+		want []model.Interval
+	}{
+		{"empty", []model.Interval{hours(1, 2)}, nil, nil},
+		{"adjacent", []model.Interval{hours(1, 2), hours(5, 6)}, []model.Interval{hours(2, 3), hours(4, 5)}, nil},
+		{"later interval", []model.Interval{hours(1, 2), hours(5, 6)}, []model.Interval{hours(3, 4), hours(5, 7)}, []model.Interval{hours(5, 7)}},
+		{"inside long interval", []model.Interval{hours(1, 24)}, []model.Interval{hours(0, 1), hours(12, 13), hours(20, 30)}, []model.Interval{hours(12, 13), hours(20, 30)}},
+		{"across intervals", []model.Interval{hours(1, 2), hours(3, 4)}, []model.Interval{hours(0, 5)}, []model.Interval{hours(0, 5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlapping(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("overlapping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Время в часах от начала 2023-05-01 UTC в формате RFC 3339
+func at(hour int) string {
+	return hours(hour, hour).Start.Format(time.RFC3339)
+}
+
+// Добавление пользователю userID события с from до to часов от начала 2023-05-01 UTC
+func insertHours(t *testing.T, s IEventService, userID, from, to int) int {
+	t.Helper()
+
+	id, _, err := s.Insert(userID, InsertEventDTO{Start: at(from), End: at(to), Description: "busy"})
+	if err != nil {
+		t.Fatalf("inserting event: %v", err)
+	}
+
+	return id
+}
+
+func Test_eventService_FindFreeSlot(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+	insertHours(t, s, 1, 0, 9)
+	insertHours(t, s, 1, 12, 13)
+	insertHours(t, s, 2, 10, 11)
+	insertHours(t, s, 2, 14, 24)
+
+	tests := []struct {
+		name     string
+		userIDs  []int
+		duration time.Duration
+		want     model.Interval
+		err      error
+	}{
+		{"first gap of one user", []int{1}, time.Hour, hours(9, 10), nil},
+		{"gap between users' events", []int{1, 2}, time.Hour, hours(9, 10), nil},
+		{"gap long enough", []int{1, 2}, 2 * time.Hour, hours(24, 26), nil},
+		{"from period start", []int{2}, time.Hour, hours(0, 1), nil},
+		{"nobody busy", []int{3}, 3 * time.Hour, hours(0, 3), nil},
+		{"no gap in period", []int{1, 2}, 25 * time.Hour, model.Interval{}, model.ErrFreeSlotNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FindFreeSlot(tt.userIDs, "2023-05-01", "2023-05-02", "[]", "", tt.duration)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("FindFreeSlot() error = %v, want %v", err, tt.err)
+			}
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("FindFreeSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventService_conflicts(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+	insertHours(t, s, 2, 10, 11)
+	insertHours(t, s, 3, 10, 11)
+
+	meeting := insertHours(t, s, 1, 12, 13)
+	if _, err := s.InviteAttendees(1, meeting, []int{2, 3}); err != nil {
+		t.Fatalf("inviting attendees: %v", err)
+	}
+	if _, err := s.RespondToInvitation(3, meeting, model.RSVPDeclined); err != nil {
+		t.Fatalf("declining invitation: %v", err)
+	}
+
+	// Перенос на время, занятое участником 2; отклонивший приглашение участник 3 не учитывается
+	dto := UpdateEventDTO{Start: at(10), End: at(12), Description: "meeting", RejectOnConflict: true}
+	if _, err := s.Update(1, meeting, 0, dto); !errors.Is(err, model.ErrScheduleConflict) {
+		t.Fatalf("Update() in reject mode error = %v, want ErrScheduleConflict", err)
+	}
+	if event, _ := s.GetByID(1, meeting); event.Start != at(12) {
+		t.Errorf("rejected update changed event start to %s", event.Start)
+	}
+
+	// Без режима reject изменение записывается с предупреждением
+	dto.RejectOnConflict = false
+	conflicts, err := s.Update(1, meeting, 0, dto)
+	if err != nil {
+		t.Fatalf("Update() in warn mode error = %v", err)
+	}
+	want := []Conflict{{UserID: 2, Interval: hours(10, 11)}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("Update() conflicts = %v, want %v", conflicts, want)
+	}
+
+	// Прежнее время самого события не считается занятым
+	start, end := at(11), at(13)
+	if _, err := s.Patch(1, meeting, 0, PatchEventDTO{End: &end, RejectOnConflict: true}); !errors.Is(err, model.ErrScheduleConflict) {
+		t.Fatalf("Patch() over attendee's event error = %v, want ErrScheduleConflict", err)
+	}
+	conflicts, err = s.Patch(1, meeting, 0, PatchEventDTO{Start: &start, End: &end, RejectOnConflict: true})
+	if err != nil || len(conflicts) != 0 {
+		t.Errorf("Patch() over own previous time = %v, %v, want no conflicts", conflicts, err)
+	}
+}
+
+func Test_eventService_conflictsOfSeries(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+
+	// Событие через неделю пересекается с повторением ежедневной серии
+	insertHours(t, s, 1, 7*24+9, 7*24+10)
+	dto := InsertEventDTO{Start: at(9), End: at(10), Recurrence: &model.Recurrence{Freq: model.FreqDaily}, RejectOnConflict: true}
+	if _, _, err := s.Insert(1, dto); !errors.Is(err, model.ErrScheduleConflict) {
+		t.Fatalf("Insert() of series error = %v, want ErrScheduleConflict", err)
+	}
+
+	dto.RejectOnConflict = false
+	seriesID, conflicts, err := s.Insert(1, dto)
+	if err != nil || len(conflicts) != 1 {
+		t.Fatalf("Insert() of series in warn mode = %v, %v, want one conflict", conflicts, err)
+	}
+
+	// Перенос повторения не пересекается с ним самим, но пересекается с другими повторениями
+	occurrence := UpdateEventDTO{Start: "2023-05-03T09:30:00Z", End: "2023-05-03T10:30:00Z", RejectOnConflict: true}
+	if _, _, err := s.UpdateOccurrence(1, seriesID, "2023-05-03", 0, occurrence); err != nil {
+		t.Errorf("UpdateOccurrence() over own time error = %v", err)
+	}
+	occurrence = UpdateEventDTO{Start: "2023-05-03T08:00:00Z", End: "2023-05-04T09:30:00Z", RejectOnConflict: true}
+	if _, _, err := s.UpdateOccurrence(1, seriesID, "2023-05-03", 0, occurrence); !errors.Is(err, model.ErrScheduleConflict) {
+		t.Errorf("UpdateOccurrence() over next occurrence error = %v, want ErrScheduleConflict", err)
+	}
+}
+
+func Test_eventService_conflictsConcurrent(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+
+	// Одновременные добавления на одно время: проверка под мьютексом пропускает только одно
+	const clients = 16
+	var inserted sync.WaitGroup
+	errs := make(chan error, clients)
+	for i := 0; i < clients; i++ {
+		inserted.Add(1)
+		go func() {
+			defer inserted.Done()
+			_, _, err := s.Insert(1, InsertEventDTO{Start: at(9), End: at(10), RejectOnConflict: true})
+			errs <- err
+		}()
+	}
+	inserted.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, model.ErrScheduleConflict):
+			t.Errorf("Insert() error = %v, want ErrScheduleConflict", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d concurrent inserts succeeded, want 1", succeeded)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+	"dev11/calendar/internal/repository"
+	"fmt"
+	"time"
+)
+
+// Получение событий пользователя userID за период для выгрузки в iCalendar.
+// Серия возвращается один раз целиком, а не развернутыми повторениями
+func (s *eventService) Export(userID int, from, to, bounds, tz string) ([]model.Event, error) {
+	page, err := s.GetRange(userID, from, to, bounds, tz, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	exported := []model.Event{}
+	seen := map[int]bool{}
+	for _, event := range page.Events {
+		if seen[event.ID] {
+			continue
+		}
+		seen[event.ID] = true
+
+		// Повторение заменяется самой серией
+		if event.IsRecurring() {
+			if event, err = s.repo.GetByID(event.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		exported = append(exported, event)
+	}
+
+	return exported, nil
+}
+
+// Импорт событий пользователю userID.
+// События с UID, уже импортированным этим пользователем, заменяются, поэтому повторный импорт не создает дублей.
+// Файл импортируется целиком или не импортируется вовсе.
+// Возвращает количество созданных и обновленных событий
+func (s *eventService) Import(userID int, events []model.Event) (created, updated int, err error) {
+	// Все события проверяются до изменений, чтобы некорректный файл не импортировался частично
+	for _, event := range events {
+		if _, _, err := event.Interval(time.UTC); err != nil {
+			return 0, 0, fmt.Errorf("event %s: %w", event.UID, err)
+		}
+
+		if event.IsRecurring() {
+			if err := event.Recurrence.Validate(); err != nil {
+				return 0, 0, fmt.Errorf("event %s: %w", event.UID, err)
+			}
+		}
+	}
+
+	// Все события записываются одним атомарным пакетом: ошибка любого из них отменяет импорт
+	mutations := make([]repository.Mutation, 0, len(events))
+	for _, event := range events {
+		event.UserId = userID
+		mutations = append(mutations, repository.Mutation{Kind: repository.MutationUpsert, Event: event})
+	}
+
+	results, err := s.repo.Apply(mutations, true)
+	if err != nil {
+		s.logError("error while importing events", err, "user_id", userID)
+		return 0, 0, err
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			s.logError("error while importing event", result.Err, "user_id", userID, "uid", events[i].UID)
+			return 0, 0, fmt.Errorf("event %s: %w", events[i].UID, result.Err)
+		}
+	}
+
+	for _, result := range results {
+		if result.Inserted {
+			created++
+			s.publish(bus.ChangeCreated, userID, result.ID)
+		} else {
+			updated++
+			s.publish(bus.ChangeUpdated, userID, result.ID)
+		}
+	}
+
+	return created, updated, nil
+}
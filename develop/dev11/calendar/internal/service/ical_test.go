@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+)
+
+func Test_eventService_Import(t *testing.T) {
+	s := NewEventService(newTestRepository(t), bus.NewBus(), discardLogger, testMaxRangeDays)
+
+	events := []model.Event{
+		{UID: "a@example.com", Date: "2023-05-01", Description: "a"},
+		{UID: "b@example.com", Date: "2023-05-02", Start: "2023-05-02T10:00:00Z", End: "2023-05-02T11:00:00Z", Description: "b"},
+	}
+
+	created, updated, err := s.Import(1, events)
+	if err != nil || created != 2 || updated != 0 {
+		t.Fatalf("Import() = %d, %d, %v, want 2 created", created, updated, err)
+	}
+
+	// Повторный импорт заменяет события с теми же UID
+	events[0].Description = "a2"
+	created, updated, err = s.Import(1, events)
+	if err != nil || created != 0 || updated != 2 {
+		t.Fatalf("second Import() = %d, %d, %v, want 2 updated", created, updated, err)
+	}
+
+	page, err := s.GetRange(1, "2023-05-01", "2023-05-02", "[]", "", ListOptions{})
+	if err != nil {
+		t.Fatalf("getting events: %v", err)
+	}
+	if len(page.Events) != 2 || page.Events[0].Description != "a2" {
+		t.Errorf("events after reimport = %+v", page.Events)
+	}
+
+	// Файл с некорректным событием не импортируется частично
+	invalid := []model.Event{
+		{UID: "c@example.com", Date: "2023-05-03", Description: "c"},
+		{UID: "d@example.com", Date: "2023-05-03", Start: "2023-05-03T11:00:00Z", End: "2023-05-03T10:00:00Z", Description: "d"},
+	}
+	if _, _, err := s.Import(1, invalid); err == nil {
+		t.Fatal("Import() of an invalid event succeeded")
+	}
+	if page, _ := s.GetRange(1, "2023-05-03", "2023-05-03", "[]", "", ListOptions{}); len(page.Events) != 0 {
+		t.Errorf("events of a failed import = %+v", page.Events)
+	}
+}
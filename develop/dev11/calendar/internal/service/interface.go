@@ -2,15 +2,33 @@ package service
 
 import (
 	"dev11/calendar/internal/model"
+	"time"
 )
 
 type IEventService interface {
-	// GetById(id int) (model.Event, error)
 	SaveEvents() error
-	Insert(dto InsertEventDTO) int
-	Update(id int, dto UpdateEventDTO) error
-	Remove(id int) error
-	GetForDay(day string) ([]model.Event, error)
-	GetForWeek(day string) ([]model.Event, error)
-	GetForMonth(day string) ([]model.Event, error)
+	PendingChanges() int
+	Insert(userID int, dto InsertEventDTO) (int, []Conflict, error)
+	Update(userID, id, version int, dto UpdateEventDTO) ([]Conflict, error)
+	UpdateOccurrence(userID, id int, date string, version int, dto UpdateEventDTO) (int, []Conflict, error)
+	Patch(userID, id, version int, dto PatchEventDTO) ([]Conflict, error)
+	Remove(userID, id, version int) error
+	RemoveOccurrence(userID, id int, date string, version int) error
+	Batch(userID int, ops []BatchOperationDTO, atomic bool) ([]BatchResult, error)
+	InviteAttendees(userID, id int, attendeeIDs []int) (model.Event, error)
+	RespondToInvitation(userID, id int, status string) (model.Event, error)
+	RemoveAttendee(userID, id, attendeeID int) error
+	GetByID(userID, id int) (model.Event, error)
+	GetRange(userID int, from, to, bounds, tz string, opts ListOptions) (EventPage, error)
+	GetForDay(userID int, day, tz string, opts ListOptions) (EventPage, error)
+	GetForWeek(userID int, day, tz string, opts ListOptions) (EventPage, error)
+	GetForMonth(userID int, day, tz string, opts ListOptions) (EventPage, error)
+	FreeBusy(userIDs []int, from, to, bounds, tz string) ([]UserBusy, error)
+	FindFreeSlot(userIDs []int, from, to, bounds, tz string, duration time.Duration) (model.Interval, error)
+	Export(userID int, from, to, bounds, tz string) ([]model.Event, error)
+	Import(userID int, events []model.Event) (created, updated int, err error)
+	GetDeleted(userID int) ([]model.Event, error)
+	Restore(userID, id int) error
+	Purge(userID, id int) error
+	PurgeDeleted(before time.Time) (int, error)
 }
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"container/heap"
+	"dev11/calendar/internal/model"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибка некорректного курсора страницы
+var ErrInvalidCursor = model.NewValidationError("cursor", "is invalid")
+
+// Параметры выборки списка событий.
+// Нулевой Limit означает выборку без ограничения, Cursor - курсор next_cursor предыдущей страницы.
+// Ненулевой UserID оставляет только события этого пользователя,
+// непустой Description - события, описание которых содержит подстроку без учета регистра
+type ListOptions struct {
+	Limit       int
+	Cursor      string
+	UserID      int
+	Description string
+}
+
+// Страница событий. Пустой NextCursor означает последнюю страницу
+type EventPage struct {
+	Events     []model.Event
+	NextCursor string
+}
+
+// Фильтрация, упорядочивание по дате и ID и выборка страницы событий events по параметрам opts.
+// События до курсора отбрасываются при фильтрации, а при ненулевом Limit собираются только
+// Limit+1 первых по порядку событий, поэтому упорядочивается не весь период, а одна страница
+func paginate(events []model.Event, opts ListOptions) (EventPage, error) {
+	// Страница начинается после события курсора
+	var after *pageKey
+	if opts.Cursor != "" {
+		key, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return EventPage{}, err
+		}
+		after = &key
+	}
+
+	selected := &pageHeap{}
+	description := strings.ToLower(opts.Description)
+	for _, event := range events {
+		if opts.UserID != 0 && event.UserId != opts.UserID {
+			continue
+		}
+		if description != "" && !strings.Contains(strings.ToLower(event.Description), description) {
+			continue
+		}
+		if after != nil && !after.less(pageKeyOf(event)) {
+			continue
+		}
+
+		// Лишнее событие нужно только для признака следующей страницы
+		if opts.Limit == 0 || selected.Len() <= opts.Limit {
+			heap.Push(selected, event)
+			continue
+		}
+		if pageKeyOf(event).less(pageKeyOf((*selected)[0])) {
+			(*selected)[0] = event
+			heap.Fix(selected, 0)
+		}
+	}
+
+	page := []model.Event(*selected)
+	sort.Slice(page, func(i, j int) bool {
+		return pageKeyOf(page[i]).less(pageKeyOf(page[j]))
+	})
+
+	if opts.Limit == 0 || len(page) <= opts.Limit {
+		return EventPage{Events: page}, nil
+	}
+
+	page = page[:opts.Limit]
+	return EventPage{Events: page, NextCursor: pageKeyOf(page[len(page)-1]).encode()}, nil
+}
+
+// Куча событий с наибольшим ключом порядка в корне
+type pageHeap []model.Event
+
+func (h pageHeap) Len() int           { return len(h) }
+func (h pageHeap) Less(i, j int) bool { return pageKeyOf(h[j]).less(pageKeyOf(h[i])) }
+func (h pageHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *pageHeap) Push(x any) { *h = append(*h, x.(model.Event)) }
+
+func (h *pageHeap) Pop() any {
+	old := *h
+	event := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return event
+}
+
+// Ключ порядка событий в списке: дата, затем ID.
+// Повторения одной серии имеют общий ID, но разные даты
+type pageKey struct {
+	date string
+	id   int
+}
+
+// Ключ порядка события event
+func pageKeyOf(event model.Event) pageKey {
+	return pageKey{date: event.Date, id: event.ID}
+}
+
+// Сравнение ключей порядка
+func (k pageKey) less(other pageKey) bool {
+	if k.date != other.date {
+		return k.date < other.date
+	}
+	return k.id < other.id
+}
+
+// Курсор, указывающий на событие с ключом k
+func (k pageKey) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(k.date + "/" + strconv.Itoa(k.id)))
+}
+
+// Разбор курсора страницы
+func decodeCursor(cursor string) (pageKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageKey{}, ErrInvalidCursor
+	}
+
+	date, id, ok := strings.Cut(string(raw), "/")
+	if !ok {
+		return pageKey{}, ErrInvalidCursor
+	}
+
+	if _, err := time.Parse(model.DateLayout, date); err != nil {
+		return pageKey{}, ErrInvalidCursor
+	}
+
+	key := pageKey{date: date}
+	if key.id, err = strconv.Atoi(id); err != nil || key.id <= 0 {
+		return pageKey{}, ErrInvalidCursor
+	}
+
+	return key, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"dev11/calendar/internal/model"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Ключи "дата/ID" событий страницы
+func pageKeys(events []model.Event) []pageKey {
+	keys := []pageKey{}
+	for _, event := range events {
+		keys = append(keys, pageKeyOf(event))
+	}
+	return keys
+}
+
+func Test_paginate(t *testing.T) {
+	events := []model.Event{
+		{ID: 3, UserId: 1, Date: "2023-05-02", Description: "Standup"},
+		{ID: 1, UserId: 1, Date: "2023-05-02", Description: "Lunch"},
+		{ID: 2, UserId: 2, Date: "2023-05-01", Description: "standup notes"},
+		{ID: 4, UserId: 1, Date: "2023-05-01", Description: "Review"},
+		// Повторение серии с тем же ID в другую дату
+		{ID: 3, UserId: 1, Date: "2023-05-03", Description: "Standup"},
+	}
+
+	t.Run("stable order", func(t *testing.T) {
+		page, err := paginate(events, ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []pageKey{{"2023-05-01", 2}, {"2023-05-01", 4}, {"2023-05-02", 1}, {"2023-05-02", 3}, {"2023-05-03", 3}}
+		if got := pageKeys(page.Events); !reflect.DeepEqual(got, want) || page.NextCursor != "" {
+			t.Errorf("paginate() = %v, %q, want %v without cursor", got, page.NextCursor, want)
+		}
+	})
+
+	t.Run("cursor walks all pages", func(t *testing.T) {
+		var got []pageKey
+		opts := ListOptions{Limit: 2}
+		for pages := 0; ; pages++ {
+			if pages > len(events) {
+				t.Fatal("pagination does not terminate")
+			}
+
+			page, err := paginate(events, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Events) > opts.Limit {
+				t.Fatalf("page has %d events, limit %d", len(page.Events), opts.Limit)
+			}
+
+			got = append(got, pageKeys(page.Events)...)
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+
+		all, _ := paginate(events, ListOptions{})
+		if want := pageKeys(all.Events); !reflect.DeepEqual(got, want) {
+			t.Errorf("pages = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		page, err := paginate(events, ListOptions{UserID: 1, Description: "STAND"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []pageKey{{"2023-05-02", 3}, {"2023-05-03", 3}}
+		if got := pageKeys(page.Events); !reflect.DeepEqual(got, want) {
+			t.Errorf("paginate() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("limit on many events", func(t *testing.T) {
+		many := []model.Event{}
+		for i := 0; i < 100; i++ {
+			// Даты и ID перемешаны относительно порядка во входных данных
+			id := (i*37)%100 + 1
+			many = append(many, model.Event{ID: id, UserId: 1, Date: fmt.Sprintf("2023-05-%02d", id%28+1)})
+		}
+
+		all, _ := paginate(many, ListOptions{})
+		want := pageKeys(all.Events)
+
+		var got []pageKey
+		opts := ListOptions{Limit: 7}
+		for {
+			page, err := paginate(many, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, pageKeys(page.Events)...)
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("pages = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"%%%", "MjAyMy0wNS0wMQ", pageKey{"2023-13-01", 1}.encode(), pageKey{"2023-05-01", 0}.encode()} {
+			if _, err := paginate(events, ListOptions{Cursor: cursor}); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("cursor %q: expected ErrInvalidCursor, got %v", cursor, err)
+			}
+		}
+	})
+}
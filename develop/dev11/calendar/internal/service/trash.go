@@ -0,0 +1,70 @@
+package service
+
+import (
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/internal/model"
+	"time"
+)
+
+// Получение удаленных событий пользователя userID
+func (s *eventService) GetDeleted(userID int) ([]model.Event, error) {
+	events, err := s.repo.GetDeleted()
+	if err != nil {
+		s.logError("error while getting deleted events", err, "user_id", userID)
+	}
+
+	return ownedBy(events, userID), err
+}
+
+// Восстановление удаленного события id пользователя userID
+func (s *eventService) Restore(userID, id int) error {
+	if _, err := s.getOwnedDeleted(userID, id); err != nil {
+		return err
+	}
+
+	err := s.repo.Restore(id)
+	if err != nil {
+		s.logError("error while restoring event", err, "user_id", userID, "event_id", id)
+		return err
+	}
+
+	s.publish(bus.ChangeRestored, userID, id)
+	return nil
+}
+
+// Окончательное удаление события id пользователя userID из корзины
+func (s *eventService) Purge(userID, id int) error {
+	if _, err := s.getOwnedDeleted(userID, id); err != nil {
+		return err
+	}
+
+	err := s.repo.Purge(id)
+	if err != nil {
+		s.logError("error while purging event", err, "user_id", userID, "event_id", id)
+	}
+	return err
+}
+
+// Окончательное удаление всех событий, удаленных раньше момента before
+func (s *eventService) PurgeDeleted(before time.Time) (int, error) {
+	purged, err := s.repo.PurgeDeletedBefore(before)
+	if err != nil {
+		s.logError("error while purging deleted events", err)
+	}
+	return purged, err
+}
+
+// Получение удаленного события id с проверкой, что оно принадлежит пользователю userID.
+// Чужое удаленное событие для пользователя не существует
+func (s *eventService) getOwnedDeleted(userID, id int) (model.Event, error) {
+	event, err := s.repo.GetDeletedByID(id)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	if event.UserId != userID {
+		return model.Event{}, model.ErrEventNotFound
+	}
+
+	return event, nil
+}
@@ -0,0 +1,19 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+)
+
+// Источник снимков: сохраняет события, сообщает о несохраненных изменениях
+// и окончательно удаляет давно удаленные события
+type ISnapshotter interface {
+	SaveEvents() error
+	PendingChanges() int
+	PurgeDeleted(before time.Time) (int, error)
+}
+
+type ISaver interface {
+	Run(ctx context.Context)
+	Status() Status
+}
@@ -0,0 +1,130 @@
+package snapshot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"dev11/calendar/pkg/logger"
+)
+
+const (
+	// Период проверки условий для снимка по умолчанию
+	checkPeriod = time.Second
+	// Период очистки корзины
+	purgePeriod = time.Hour
+)
+
+// Состояние последнего снимка
+type Status struct {
+	LastSnapshot time.Time `json:"last_snapshot"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// Фоновый сохранятель снимков. Снимок делается, когда с прошлого прошло interval
+// или накопилось maxMutations несохраненных изменений. Раз в purgePeriod, независимо от изменений,
+// окончательно удаляются события, удаленные раньше чем retention назад (нулевой retention хранит
+// их бессрочно); очищенные события попадают в следующий снимок как обычные изменения
+type saver struct {
+	snapshotter  ISnapshotter
+	interval     time.Duration
+	maxMutations int
+	retention    time.Duration
+	logger       logger.ILogger
+	// Период проверки условий для снимка
+	checkPeriod time.Duration
+	// Время последней попытки снимка и последней очистки корзины. Используются только циклом Run
+	lastAttempt time.Time
+	lastPurge   time.Time
+
+	mtx    sync.RWMutex
+	status Status
+}
+
+// Конструктор фонового сохранятеля снимков
+func NewSaver(snapshotter ISnapshotter, interval time.Duration, maxMutations int, retention time.Duration,
+	logger logger.ILogger) ISaver {
+	return &saver{
+		snapshotter:  snapshotter,
+		interval:     interval,
+		maxMutations: maxMutations,
+		retention:    retention,
+		logger:       logger,
+		checkPeriod:  checkPeriod,
+	}
+}
+
+// Цикл сохранения снимков. Блокируется до отмены контекста ctx
+func (s *saver) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.checkPeriod)
+	defer ticker.Stop()
+
+	s.lastAttempt = time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.tick(now)
+		}
+	}
+}
+
+// Очистка корзины и проверка условий для снимка в момент now с сохранением снимка при их выполнении
+func (s *saver) tick(now time.Time) {
+	// Очистка не зависит от изменений, иначе на простаивающем экземпляре корзина не очищалась бы
+	if s.retention > 0 && now.Sub(s.lastPurge) >= purgePeriod {
+		s.lastPurge = now
+		s.purge(now)
+	}
+
+	pending := s.snapshotter.PendingChanges()
+
+	// Без изменений снимок не нужен
+	if pending == 0 {
+		return
+	}
+
+	if now.Sub(s.lastAttempt) < s.interval && pending < s.maxMutations {
+		return
+	}
+
+	s.lastAttempt = now
+	s.save(now)
+}
+
+// Состояние последнего снимка
+func (s *saver) Status() Status {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.status
+}
+
+// Окончательное удаление событий, удаленных раньше чем retention до момента now.
+// При ошибке события останутся до следующей очистки
+func (s *saver) purge(now time.Time) {
+	purged, err := s.snapshotter.PurgeDeleted(now.Add(-s.retention))
+	if err != nil {
+		s.logger.Error("error while purging deleted events", "err", err)
+	} else if purged > 0 {
+		s.logger.Info("purged deleted events", "count", purged, "retention", s.retention)
+	}
+}
+
+// Сохранение снимка в момент now с обновлением состояния
+func (s *saver) save(now time.Time) {
+	err := s.snapshotter.SaveEvents()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err != nil {
+		s.logger.Error("error while saving snapshot", "err", err)
+		s.status.LastError = err.Error()
+		return
+	}
+
+	s.status = Status{LastSnapshot: now}
+}
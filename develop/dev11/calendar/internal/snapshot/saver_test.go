@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"context"
+	"dev11/calendar/pkg/logger"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Логгер, отбрасывающий записи
+var discardLogger = logger.New(io.Discard, logger.LevelDebug)
+
+// Источник снимков с заданным числом несохраненных изменений, запоминающий вызовы
+type fakeSnapshotter struct {
+	mtx          sync.Mutex
+	pending      int
+	saveErr      error
+	saves        int
+	purgedBefore []time.Time
+}
+
+func (f *fakeSnapshotter) SaveEvents() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.saves++
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.pending = 0
+	return nil
+}
+
+func (f *fakeSnapshotter) PendingChanges() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.pending
+}
+
+func (f *fakeSnapshotter) PurgeDeleted(before time.Time) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.purgedBefore = append(f.purgedBefore, before)
+	return 0, nil
+}
+
+func (f *fakeSnapshotter) saveCount() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.saves
+}
+
+func newSaver(snapshotter ISnapshotter, retention time.Duration) *saver {
+	return NewSaver(snapshotter, time.Minute, 10, retention, discardLogger).(*saver)
+}
+
+func Test_saver_tick(t *testing.T) {
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		pending int
+		elapsed time.Duration
+		save    bool
+	}{
+		{"no changes", 0, time.Hour, false},
+		{"few changes before interval", 9, 30 * time.Second, false},
+		{"max mutations before interval", 10, 30 * time.Second, true},
+		{"interval elapsed", 1, time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotter := &fakeSnapshotter{pending: tt.pending}
+			s := newSaver(snapshotter, 0)
+			s.lastAttempt = start
+
+			s.tick(start.Add(tt.elapsed))
+
+			if saved := snapshotter.saveCount() == 1; saved != tt.save {
+				t.Errorf("saved = %v, want %v", saved, tt.save)
+			}
+		})
+	}
+}
+
+func Test_saver_purgeSchedule(t *testing.T) {
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	retention := 24 * time.Hour
+
+	// Экземпляр без изменений: корзина очищается по своему расписанию, снимки не делаются
+	snapshotter := &fakeSnapshotter{}
+	s := newSaver(snapshotter, retention)
+	s.lastAttempt = start
+
+	for _, elapsed := range []time.Duration{0, 30 * time.Minute, time.Hour, 90 * time.Minute, 2 * time.Hour} {
+		s.tick(start.Add(elapsed))
+	}
+
+	want := []time.Time{start.Add(-retention), start.Add(time.Hour - retention), start.Add(2*time.Hour - retention)}
+	if !reflect.DeepEqual(snapshotter.purgedBefore, want) {
+		t.Errorf("purged before %v, want %v", snapshotter.purgedBefore, want)
+	}
+	if snapshotter.saveCount() != 0 {
+		t.Errorf("saves = %d, want none without changes", snapshotter.saveCount())
+	}
+
+	// Нулевой retention отключает очистку
+	snapshotter = &fakeSnapshotter{}
+	newSaver(snapshotter, 0).tick(start)
+	if len(snapshotter.purgedBefore) != 0 {
+		t.Errorf("purged with zero retention: %v", snapshotter.purgedBefore)
+	}
+}
+
+func Test_saver_Status(t *testing.T) {
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	snapshotter := &fakeSnapshotter{pending: 1, saveErr: errors.New("disk full")}
+	s := newSaver(snapshotter, 0)
+	s.lastAttempt = start
+
+	// Неудачный снимок сохраняет время прошлого и запоминает ошибку
+	s.tick(start.Add(time.Minute))
+	if status := s.Status(); !status.LastSnapshot.IsZero() || status.LastError != "disk full" {
+		t.Errorf("Status() after failure = %+v", status)
+	}
+
+	// Повтор выполняется не раньше следующего интервала
+	s.tick(start.Add(90 * time.Second))
+	if snapshotter.saveCount() != 1 {
+		t.Errorf("saves = %d, want no retry before interval", snapshotter.saveCount())
+	}
+
+	snapshotter.saveErr = nil
+	s.tick(start.Add(2 * time.Minute))
+	if status := s.Status(); !status.LastSnapshot.Equal(start.Add(2*time.Minute)) || status.LastError != "" {
+		t.Errorf("Status() after success = %+v", status)
+	}
+}
+
+func Test_saver_Run(t *testing.T) {
+	snapshotter := &fakeSnapshotter{pending: 10}
+	s := newSaver(snapshotter, 0)
+	s.checkPeriod = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for snapshotter.saveCount() == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("snapshot not saved")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+}
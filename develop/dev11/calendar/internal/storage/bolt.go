@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"dev11/calendar/internal/model"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Имя бакета с событиями
+var eventsBucket = []byte("events")
+
+// Хранилище событий на основе встроенной базы bbolt.
+// Каждое изменение записывается в отдельной транзакции
+type boltStorage struct {
+	db *bolt.DB
+}
+
+// Конструктор хранилища событий bbolt
+func NewBoltStorage(fileName string) (IStorage, error) {
+	// Создание директории под файл базы
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, fmt.Errorf("making dir: %w", err)
+	}
+
+	// Открытие базы. Таймаут защищает от зависания, если файл заблокирован другим процессом
+	db, err := bolt.Open(fileName, 0666, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening bolt db: %w", err)
+	}
+
+	// Создание бакета событий
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(eventsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating bucket: %w", err)
+	}
+
+	return &boltStorage{db: db}, nil
+}
+
+// Получение событий из хранилища
+func (s *boltStorage) Get() ([]model.Event, error) {
+	events := []model.Event{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(eventsBucket).ForEach(func(_, value []byte) error {
+			var event model.Event
+			if err := json.Unmarshal(value, &event); err != nil {
+				return fmt.Errorf("decoding event: %w", err)
+			}
+
+			events = append(events, event)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Сохранение всех событий в хранилище. Содержимое бакета заменяется целиком в одной транзакции
+func (s *boltStorage) Save(events []model.Event) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(eventsBucket); err != nil {
+			return fmt.Errorf("deleting bucket: %w", err)
+		}
+
+		bucket, err := tx.CreateBucket(eventsBucket)
+		if err != nil {
+			return fmt.Errorf("creating bucket: %w", err)
+		}
+
+		for _, event := range events {
+			if err := putEvent(bucket, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Сохранение одного события
+func (s *boltStorage) Put(event model.Event) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putEvent(tx.Bucket(eventsBucket), event)
+	})
+}
+
+// Сохранение нескольких событий в одной транзакции
+func (s *boltStorage) PutAll(events []model.Event) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(eventsBucket)
+		for _, event := range events {
+			if err := putEvent(bucket, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Окончательное удаление события
+func (s *boltStorage) Delete(id int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(eventsBucket).Delete(eventKey(id))
+	})
+}
+
+// Окончательное удаление нескольких событий в одной транзакции
+func (s *boltStorage) DeleteAll(ids []int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(eventsBucket)
+		for _, id := range ids {
+			if err := bucket.Delete(eventKey(id)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Закрытие базы
+func (s *boltStorage) Close() error {
+	return s.db.Close()
+}
+
+// Запись события в бакет по ключу ID
+func putEvent(bucket *bolt.Bucket, event model.Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	if err := bucket.Put(eventKey(event.ID), value); err != nil {
+		return fmt.Errorf("putting event: %w", err)
+	}
+
+	return nil
+}
+
+// Ключ события. Big-endian сохраняет порядок ключей по возрастанию ID
+func eventKey(id int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"dev11/calendar/internal/model"
 )
 
-// Хранилище событий
+// Хранилище событий в одном json-файле, перезаписываемом целиком снимками
 type eventStorage struct {
 	fileName string
+	// Мьютекс, упорядочивающий перезаписи файла
+	mtx sync.Mutex
 }
 
 // Конструктор хранилища событий
@@ -51,14 +54,38 @@ func (s *eventStorage) Get() ([]model.Event, error) {
 	return events, nil
 }
 
-// Сохранение событий в хранилище
+// Сохранение событий в хранилище.
+// События пишутся во временный файл, который после fsync атомарно заменяет основной,
+// поэтому падение во время записи не портит предыдущий снимок
 func (s *eventStorage) Save(events []model.Event) error {
-	// Открытие файла с событиями
-	file, err := s.openStorageFile()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.save(events)
+}
+
+// Атомарная перезапись файла событиями events. Вызывается под мьютексом
+func (s *eventStorage) save(events []model.Event) error {
+	dir := filepath.Dir(s.fileName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("making dir: %w", err)
+	}
+
+	// Временный файл создается в той же директории, чтобы rename не пересекал файловые системы
+	file, err := os.CreateTemp(dir, filepath.Base(s.fileName)+".tmp-*")
 	if err != nil {
-		return fmt.Errorf("getting opening file: %w", err)
+		return fmt.Errorf("creating temp file: %w", err)
 	}
-	defer file.Close()
+	tmpName := file.Name()
+
+	// При любой ошибке временный файл удаляется
+	committed := false
+	defer func() {
+		if !committed {
+			file.Close()
+			os.Remove(tmpName)
+		}
+	}()
 
 	// Сериализация событий и запись в файл
 	encoder := json.NewEncoder(file)
@@ -67,6 +94,51 @@ func (s *eventStorage) Save(events []model.Event) error {
 		return fmt.Errorf("encoding events to file: %w", err)
 	}
 
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("syncing temp file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, s.fileName); err != nil {
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	committed = true
+
+	// Сброс директории на диск фиксирует саму замену файла
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("syncing dir: %w", err)
+	}
+
+	return nil
+}
+
+// Сохранение одного события. Файловое хранилище перезаписывается только целиком в Save:
+// отдельные изменения уже зафиксированы журналом и попадут в следующий снимок, а перезапись
+// файла на каждое изменение сделала бы запись пропорциональной числу событий
+func (s *eventStorage) Put(event model.Event) error {
+	return nil
+}
+
+// Сохранение нескольких событий. Как и Put, в файловом хранилище применяется только со следующим Save
+func (s *eventStorage) PutAll(events []model.Event) error {
+	return nil
+}
+
+// Окончательное удаление события. Как и Put, в файловом хранилище применяется только со следующим Save
+func (s *eventStorage) Delete(id int) error {
+	return nil
+}
+
+// Окончательное удаление нескольких событий. Как и Put, в файловом хранилище применяется только со следующим Save
+func (s *eventStorage) DeleteAll(ids []int) error {
+	return nil
+}
+
+// Закрытие хранилища. Файл открывается только на время операций, поэтому закрывать нечего
+func (s *eventStorage) Close() error {
 	return nil
 }
 
@@ -75,7 +147,7 @@ func (s *eventStorage) Save(events []model.Event) error {
 func (s *eventStorage) openStorageFile() (*os.File, error) {
 	dir := filepath.Dir(s.fileName)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		err = os.MkdirAll(dir, os.ModeDir)
+		err = os.MkdirAll(dir, 0755)
 		if err != nil {
 			return nil, fmt.Errorf("making dir: %w", err)
 		}
@@ -88,3 +160,14 @@ func (s *eventStorage) openStorageFile() (*os.File, error) {
 
 	return file, nil
 }
+
+// Сброс записи директории на диск
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"dev11/calendar/internal/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Имена файлов директории dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading dir: %v", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func Test_eventStorage_Save(t *testing.T) {
+	first := []model.Event{{ID: 1, Version: 1, UserId: 1, Date: "2023-05-01", Description: "a"}}
+	second := []model.Event{{ID: 2, Version: 1, UserId: 1, Date: "2023-05-02", Description: "b"}}
+
+	t.Run("replaces snapshot without leftovers", func(t *testing.T) {
+		dir := t.TempDir()
+		s := NewEventStorage(filepath.Join(dir, "events.json"))
+
+		for _, events := range [][]model.Event{first, second} {
+			if err := s.Save(events); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+		}
+
+		if got, _ := s.Get(); !reflect.DeepEqual(got, second) {
+			t.Errorf("Get() = %+v, want %+v", got, second)
+		}
+		if names := dirEntries(t, dir); !reflect.DeepEqual(names, []string{"events.json"}) {
+			t.Errorf("dir contains %v, want only the snapshot", names)
+		}
+	})
+
+	t.Run("torn temp file of a crashed save is ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		s := NewEventStorage(filepath.Join(dir, "events.json"))
+		if err := s.Save(first); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		// Недописанный временный файл, оставшийся после падения процесса
+		torn := filepath.Join(dir, "events.json.tmp-1")
+		if err := os.WriteFile(torn, []byte(`[{"id":2,"ver`), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, err := s.Get(); err != nil || !reflect.DeepEqual(got, first) {
+			t.Errorf("Get() = %+v, %v, want %+v", got, err, first)
+		}
+	})
+
+	t.Run("failed save removes temp file", func(t *testing.T) {
+		dir := t.TempDir()
+		target := filepath.Join(dir, "events.json")
+
+		// Непустая директория на месте файла снимка не дает выполнить rename
+		if err := os.MkdirAll(filepath.Join(target, "occupied"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := NewEventStorage(target).Save(first); err == nil {
+			t.Fatal("Save() over a directory succeeded")
+		}
+		if names := dirEntries(t, dir); !reflect.DeepEqual(names, []string{"events.json"}) {
+			t.Errorf("dir contains %v, want the temp file removed", names)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package storage
+
+import "fmt"
+
+const (
+	// Хранилище в виде одного json-файла, перезаписываемого целиком снимками.
+	// Отдельные изменения между снимками хранятся только в журнале
+	TypeFile = "file"
+	// Встроенное key-value хранилище bbolt, записывающее каждое изменение
+	TypeBolt = "bolt"
+)
+
+// Создание хранилища по его типу storageType
+func New(storageType, path string) (IStorage, error) {
+	switch storageType {
+	case TypeFile:
+		return NewEventStorage(path), nil
+	case TypeBolt:
+		return NewBoltStorage(path)
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", storageType)
+	}
+}
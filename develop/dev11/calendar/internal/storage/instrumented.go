@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"dev11/calendar/internal/metrics"
+	"dev11/calendar/internal/model"
+)
+
+// Хранилище, считающее успешные и неуспешные записи в счетчике с метками op и result
+type instrumentedStorage struct {
+	IStorage
+	writes *metrics.CounterVec
+}
+
+// Обертка хранилища storage, считающая его записи в writes
+func NewInstrumented(storage IStorage, writes *metrics.CounterVec) IStorage {
+	return &instrumentedStorage{
+		IStorage: storage,
+		writes:   writes,
+	}
+}
+
+// Сохранение снимка событий
+func (s *instrumentedStorage) Save(events []model.Event) error {
+	err := s.IStorage.Save(events)
+	s.writes.Inc("save", result(err))
+
+	return err
+}
+
+// Сохранение одного события
+func (s *instrumentedStorage) Put(event model.Event) error {
+	err := s.IStorage.Put(event)
+	s.writes.Inc("put", result(err))
+
+	return err
+}
+
+// Сохранение нескольких событий одной записью
+func (s *instrumentedStorage) PutAll(events []model.Event) error {
+	err := s.IStorage.PutAll(events)
+	s.writes.Inc("put_all", result(err))
+
+	return err
+}
+
+// Окончательное удаление события
+func (s *instrumentedStorage) Delete(id int) error {
+	err := s.IStorage.Delete(id)
+	s.writes.Inc("delete", result(err))
+
+	return err
+}
+
+// Окончательное удаление нескольких событий одной записью
+func (s *instrumentedStorage) DeleteAll(ids []int) error {
+	err := s.IStorage.DeleteAll(ids)
+	s.writes.Inc("delete_all", result(err))
+
+	return err
+}
+
+// Значение метки результата записи
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
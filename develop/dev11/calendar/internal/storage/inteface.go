@@ -5,4 +5,9 @@ import "dev11/calendar/internal/model"
 type IStorage interface {
 	Get() ([]model.Event, error)
 	Save([]model.Event) error
+	Put(event model.Event) error
+	PutAll(events []model.Event) error
+	Delete(id int) error
+	DeleteAll(ids []int) error
+	Close() error
 }
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"dev11/calendar/internal/model"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// События хранилища, упорядоченные по ID
+func sortedEvents(t *testing.T, s IStorage) []model.Event {
+	t.Helper()
+
+	events, err := s.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	return events
+}
+
+func Test_storage_RoundTrip(t *testing.T) {
+	// Файловое хранилище записывает только снимки, а отдельные изменения оставляет журналу
+	tests := []struct {
+		storageType string
+		perOp       bool
+	}{
+		{TypeFile, false},
+		{TypeBolt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.storageType, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nested", "events."+tt.storageType)
+
+			s, err := New(tt.storageType, path)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if events := sortedEvents(t, s); len(events) != 0 {
+				t.Fatalf("new storage has events %v", events)
+			}
+
+			snapshot := []model.Event{
+				{ID: 2, Version: 1, UserId: 1, Date: "2023-05-02", Description: "b"},
+				{ID: 1, Version: 1, UserId: 1, Date: "2023-05-01", Description: "a",
+					Recurrence: &model.Recurrence{Freq: model.FreqDaily}, ExDates: []string{"2023-05-03"}},
+			}
+			if err := s.Save(snapshot); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			// Отдельные записи поверх снимка
+			if err := s.Put(model.Event{ID: 2, Version: 2, UserId: 1, Date: "2023-05-02", Description: "b2"}); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if err := s.PutAll([]model.Event{
+				{ID: 3, Version: 1, UserId: 2, Start: "2023-05-04T10:00:00Z", End: "2023-05-04T11:00:00Z", Date: "2023-05-04", Description: "c"},
+				{ID: 4, Version: 1, UserId: 2, Date: "2023-05-05", Description: "d",
+					Attendees: []model.Attendee{{UserID: 1, Status: model.RSVPAccepted}}},
+			}); err != nil {
+				t.Fatalf("PutAll() error = %v", err)
+			}
+			if err := s.Delete(1); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := s.Delete(100); err != nil {
+				t.Fatalf("Delete() of a missing event error = %v", err)
+			}
+			if err := s.DeleteAll([]int{3, 100}); err != nil {
+				t.Fatalf("DeleteAll() error = %v", err)
+			}
+
+			want := []model.Event{snapshot[1], snapshot[0]}
+			if tt.perOp {
+				want = []model.Event{
+					{ID: 2, Version: 2, UserId: 1, Date: "2023-05-02", Description: "b2"},
+					{ID: 4, Version: 1, UserId: 2, Date: "2023-05-05", Description: "d",
+						Attendees: []model.Attendee{{UserID: 1, Status: model.RSVPAccepted}}},
+				}
+			}
+			if got := sortedEvents(t, s); !reflect.DeepEqual(got, want) {
+				t.Errorf("Get() = %+v, want %+v", got, want)
+			}
+
+			// Записи переживают повторное открытие хранилища
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			reopened, err := New(tt.storageType, path)
+			if err != nil {
+				t.Fatalf("reopening storage: %v", err)
+			}
+			defer reopened.Close()
+
+			if got := sortedEvents(t, reopened); !reflect.DeepEqual(got, want) {
+				t.Errorf("Get() after reopening = %+v, want %+v", got, want)
+			}
+
+			// Снимок заменяет все события
+			if err := reopened.Save(want[:1]); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if got := sortedEvents(t, reopened); !reflect.DeepEqual(got, want[:1]) {
+				t.Errorf("Get() after Save() = %+v, want %+v", got, want[:1])
+			}
+		})
+	}
+}
+
+func Test_New_unknownType(t *testing.T) {
+	if _, err := New("memory", filepath.Join(t.TempDir(), "events")); err == nil {
+		t.Error("New() with unknown type succeeded")
+	}
+}
@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/pkg/logger"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Заголовки запроса доставки изменения
+const (
+	// Подпись "sha256=<hex>" - HMAC-SHA256 на секрете от строки "<timestamp>.<тело запроса>"
+	SignatureHeader = "X-Calendar-Signature"
+	// Время отправки в секундах Unix, входящее в подпись
+	TimestampHeader = "X-Calendar-Timestamp"
+	// Номер изменения в шине, одинаковый для всех попыток доставки
+	DeliveryHeader = "X-Calendar-Delivery"
+	// Тип изменения
+	ChangeHeader = "X-Calendar-Change"
+)
+
+const (
+	// Таймаут одной попытки доставки
+	attemptTimeout = 10 * time.Second
+	// Наибольшая пауза между попытками доставки
+	maxBackoff = time.Minute
+	// Количество изменений, ожидающих доставки на один вебхук
+	queueSize = 256
+)
+
+// Ответ вебхука с кодом вне диапазона 2xx
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d", e.status)
+}
+
+// Рассылка изменений из шины на вебхуки. Изменение отправляется POST-запросом с телом JSON
+// и подписью. Неудачная доставка повторяется до maxAttempts раз с паузой, начинающейся с backoff
+// и удваивающейся после каждой попытки. У каждого вебхука своя очередь, поэтому недоступный
+// получатель не задерживает остальных, а изменения доставляются каждому получателю по порядку
+type dispatcher struct {
+	bus         bus.IBus
+	urls        []string
+	secret      []byte
+	maxAttempts int
+	backoff     time.Duration
+	client      *http.Client
+	logger      logger.ILogger
+}
+
+// Конструктор рассылки изменений из шины bus на адреса urls с подписью на секрете secret
+func NewDispatcher(bus bus.IBus, urls []string, secret []byte, maxAttempts int, backoff time.Duration,
+	logger logger.ILogger) IDispatcher {
+	return &dispatcher{
+		bus:         bus,
+		urls:        urls,
+		secret:      secret,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		client:      &http.Client{Timeout: attemptTimeout},
+		logger:      logger,
+	}
+}
+
+// Рассылка изменений до отмены контекста ctx или закрытия шины.
+// Изменения, не доставленные к этому моменту, отбрасываются
+func (d *dispatcher) Run(ctx context.Context) {
+	if len(d.urls) == 0 {
+		return
+	}
+
+	sub, err := d.bus.Subscribe(0, nil)
+	if err != nil {
+		return
+	}
+
+	queues := make([]chan bus.Change, len(d.urls))
+	var wg sync.WaitGroup
+	for i, url := range d.urls {
+		queues[i] = make(chan bus.Change, queueSize)
+
+		wg.Add(1)
+		go func(url string, queue <-chan bus.Change) {
+			defer wg.Done()
+			d.work(ctx, url, queue)
+		}(url, queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	// Подписка, не успевшая прочитать изменения, закрывается шиной и возобновляется
+	// с последнего полученного изменения
+	var lastID uint64
+	for {
+		lastID = d.forward(ctx, sub, queues, lastID)
+		sub.Close()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if sub, err = d.bus.Subscribe(lastID, nil); err != nil {
+			return
+		}
+		d.logger.Warn("webhook subscription resumed after overflow", "last_change_id", lastID)
+	}
+}
+
+// Передача изменений подписки sub в очереди вебхуков до закрытия подписки или отмены контекста.
+// Возвращает ID последнего полученного изменения
+func (d *dispatcher) forward(ctx context.Context, sub bus.ISubscription, queues []chan bus.Change, lastID uint64) uint64 {
+	for {
+		select {
+		case <-ctx.Done():
+			return lastID
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return lastID
+			}
+			lastID = change.ID
+
+			for i, queue := range queues {
+				select {
+				case queue <- change:
+				default:
+					d.logger.Error("webhook queue is full, dropping change", "url", d.urls[i], "change_id", change.ID)
+				}
+			}
+		}
+	}
+}
+
+// Доставка изменений из очереди queue на адрес url
+func (d *dispatcher) work(ctx context.Context, url string, queue <-chan bus.Change) {
+	for change := range queue {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := d.deliver(ctx, url, change); err != nil && ctx.Err() == nil {
+			d.logger.Error("error while delivering webhook", "url", url, "change_id", change.ID, "err", err)
+		}
+	}
+}
+
+// Доставка изменения change на адрес url с повторами
+func (d *dispatcher) deliver(ctx context.Context, url string, change bus.Change) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("encoding change: %w", err)
+	}
+
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		err = d.send(ctx, url, change, body)
+		if err == nil || attempt >= d.maxAttempts || !retryable(err) {
+			return err
+		}
+
+		d.logger.Warn("webhook delivery failed, retrying", "url", url, "change_id", change.ID,
+			"attempt", attempt, "retry_in", backoff, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// Одна попытка доставки изменения change с телом body на адрес url
+func (d *dispatcher) send(ctx context.Context, url string, change bus.Change, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(d.secret, timestamp, body))
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(change.ID, 10))
+	req.Header.Set(ChangeHeader, change.Type)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{status: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// Подпись тела запроса body, отправленного в момент timestamp, на секрете secret
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Признак ошибки, после которой доставку стоит повторить: сетевая ошибка, таймаут,
+// ответ 5xx или 429. Остальные ответы 4xx означают, что получатель отверг изменение
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.status >= 500 || status.status == http.StatusTooManyRequests ||
+			status.status == http.StatusRequestTimeout
+	}
+
+	return true
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"dev11/calendar/internal/bus"
+	"dev11/calendar/pkg/logger"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Логгер, отбрасывающий записи
+var discardLogger = logger.New(io.Discard, logger.LevelDebug)
+
+var secret = []byte("webhook-secret")
+
+// Получатель вебхука, проверяющий подпись и отвечающий статусами statuses по очереди
+// (после их окончания - 200)
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	attempts int
+	changes  chan bus.Change
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*receiver, *httptest.Server) {
+	rec := &receiver{t: t, statuses: statuses, changes: make(chan bus.Change, queueSize)}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	return rec, srv
+}
+
+func (rec *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	if got, want := r.Header.Get(SignatureHeader), Sign(secret, r.Header.Get(TimestampHeader), body); got != want {
+		rec.t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	var change bus.Change
+	if err := json.Unmarshal(body, &change); err != nil {
+		rec.t.Errorf("decoding change: %v", err)
+	}
+	if r.Header.Get(ChangeHeader) != change.Type {
+		rec.t.Errorf("change header = %q, want %q", r.Header.Get(ChangeHeader), change.Type)
+	}
+
+	rec.mu.Lock()
+	rec.attempts++
+	status := http.StatusOK
+	if len(rec.statuses) > 0 {
+		status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+	}
+	rec.mu.Unlock()
+
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		rec.changes <- change
+	}
+}
+
+func (rec *receiver) attemptCount() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.attempts
+}
+
+func newDispatcher(b bus.IBus, urls ...string) *dispatcher {
+	return NewDispatcher(b, urls, secret, 3, time.Millisecond, discardLogger).(*dispatcher)
+}
+
+func Test_dispatcher_deliver(t *testing.T) {
+	change := bus.Change{ID: 7, Type: bus.ChangeCreated, EventID: 1, UserID: 1}
+
+	tests := []struct {
+		name     string
+		statuses []int
+		ok       bool
+		attempts int
+	}{
+		{"delivered", nil, true, 1},
+		{"retried after server errors", []int{http.StatusInternalServerError, http.StatusTooManyRequests}, true, 3},
+		{"attempts exhausted", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, false, 3},
+		{"rejected without retry", []int{http.StatusBadRequest}, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, srv := newReceiver(t, tt.statuses...)
+
+			err := newDispatcher(bus.NewBus(), srv.URL).deliver(context.Background(), srv.URL, change)
+			if (err == nil) != tt.ok {
+				t.Errorf("deliver() error = %v, want success %v", err, tt.ok)
+			}
+			if rec.attemptCount() != tt.attempts {
+				t.Errorf("attempts = %d, want %d", rec.attemptCount(), tt.attempts)
+			}
+		})
+	}
+}
+
+func Test_dispatcher_Run(t *testing.T) {
+	b := bus.NewBus()
+	first, firstSrv := newReceiver(t, http.StatusServiceUnavailable)
+	second, secondSrv := newReceiver(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newDispatcher(b, firstSrv.URL, secondSrv.URL).Run(ctx)
+	}()
+
+	// Изменения публикуются, пока рассылка не подпишется на шину и не доставит одно из них
+	deadline := time.After(5 * time.Second)
+	var warmup uint64
+	for subscribed := false; !subscribed; {
+		b.Publish(bus.Change{Type: bus.ChangeUpdated, EventID: 1, UserID: 1})
+		warmup++
+
+		select {
+		case <-second.changes:
+			subscribed = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no change delivered")
+		}
+	}
+
+	for id := 2; id <= 4; id++ {
+		b.Publish(bus.Change{Type: bus.ChangeCreated, EventID: id, UserID: 1})
+	}
+
+	// Каждый получатель получает изменения по порядку, несмотря на повтор доставки у первого
+	for name, rec := range map[string]*receiver{"first": first, "second": second} {
+		var last uint64
+		for last < warmup+3 {
+			select {
+			case change := <-rec.changes:
+				if change.ID <= last {
+					t.Errorf("%s receiver: change %d after %d", name, change.ID, last)
+				}
+				last = change.ID
+			case <-deadline:
+				t.Fatalf("%s receiver: changes not delivered, last %d", name, last)
+			}
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,8 @@
+package webhook
+
+import "context"
+
+// Рассылка изменений событий на вебхуки
+type IDispatcher interface {
+	Run(ctx context.Context)
+}
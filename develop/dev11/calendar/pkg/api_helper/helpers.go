@@ -8,14 +8,30 @@ import (
 )
 
 type JsonResponse struct {
-	Error  string `json:"error,omitempty"`
-	Result any    `json:"result,omitempty"`
+	Result   any       `json:"result,omitempty"`
+	Paging   *Paging   `json:"paging,omitempty"`
+	Warnings []Warning `json:"warnings,omitempty"`
 }
 
-func ReadJSON(w http.ResponseWriter, r *http.Request, data any) error {
-	maxBytes := 1048576 // 1 mb
+// Предупреждение о выполненном запросе: Code - машиночитаемый код, Detail - описание для человека
+type Warning struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// Метаданные страницы списка. Пустой NextCursor означает последнюю страницу,
+// нулевой Limit - выборку без ограничения
+type Paging struct {
+	Count      int    `json:"count"`
+	Limit      int    `json:"limit,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
 
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+// Наибольший размер тела запроса
+const maxBodyBytes = 1048576 // 1 mb
+
+func ReadJSON(w http.ResponseWriter, r *http.Request, data any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
 
 	dec := json.NewDecoder(r.Body)
 	if err := dec.Decode(data); err != nil {
@@ -29,6 +45,13 @@ func ReadJSON(w http.ResponseWriter, r *http.Request, data any) error {
 	return nil
 }
 
+// Чтение тела запроса размером не больше 1 mb
+func ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	return io.ReadAll(r.Body)
+}
+
 func WriteJSON(w http.ResponseWriter, status int, data any, headers ...http.Header) error {
 	out, err := json.Marshal(data)
 	if err != nil {
@@ -50,14 +73,60 @@ func WriteJSON(w http.ResponseWriter, status int, data any, headers ...http.Head
 	return nil
 }
 
-func ErrorJSON(w http.ResponseWriter, err error, status ...int) error {
-	statusCode := http.StatusInternalServerError
-	if len(status) > 0 {
-		statusCode = status[0]
+// Описание ошибки в духе RFC 7807 (application/problem+json).
+// Code - машиночитаемый код ошибки, Detail - описание для человека,
+// Fields - поля запроса, не прошедшие валидацию. Error повторяет Detail для клиентов,
+// читающих поле error прежнего формата ответа {"error": ...}
+type Problem struct {
+	Type   string         `json:"type"`
+	Title  string         `json:"title"`
+	Status int            `json:"status"`
+	Code   string         `json:"code"`
+	Detail string         `json:"detail"`
+	Fields []ProblemField `json:"fields,omitempty"`
+	Error  string         `json:"error"`
+}
+
+// Поле запроса, не прошедшее валидацию
+type ProblemField struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Описание ошибки problem с незаданными типом и заголовком, заполненными по коду ответа,
+// и текстом прежнего формата, заполненным описанием для человека
+func CompleteProblem(problem Problem) Problem {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.Error == "" {
+		problem.Error = problem.Detail
 	}
 
-	var payload JsonResponse
-	payload.Error = err.Error()
+	return problem
+}
 
-	return WriteJSON(w, statusCode, payload)
+// Ответ с описанием ошибки problem. Незаданные тип и заголовок заполняются по коду ответа
+func WriteProblem(w http.ResponseWriter, problem Problem, headers ...http.Header) error {
+	out, err := json.Marshal(CompleteProblem(problem))
+	if err != nil {
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	if _, err = w.Write(out); err != nil {
+		return err
+	}
+
+	return nil
 }
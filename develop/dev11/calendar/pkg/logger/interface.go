@@ -0,0 +1,9 @@
+package logger
+
+type ILogger interface {
+	Debug(msg string, keyvals ...any)
+	Info(msg string, keyvals ...any)
+	Warn(msg string, keyvals ...any)
+	Error(msg string, keyvals ...any)
+	With(keyvals ...any) ILogger
+}
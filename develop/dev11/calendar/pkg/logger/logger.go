@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Уровень важности записи
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Названия уровней в записях и конфигурации
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// Название уровня
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Получение уровня по названию (debug, info, warn, error)
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown log level %q, should be one of debug, info, warn, error", name)
+}
+
+// Логгер, пишущий записи строками JSON.
+// Поля записи передаются парами ключ-значение и выводятся после времени, уровня и сообщения
+type logger struct {
+	out    *output
+	level  Level
+	fields []any
+}
+
+// Поток вывода, общий для логгера и его производных
+type output struct {
+	mtx sync.Mutex
+	w   io.Writer
+}
+
+// Конструктор логгера, пишущего в w записи с уровнем не ниже level
+func New(w io.Writer, level Level) ILogger {
+	return &logger{
+		out:   &output{w: w},
+		level: level,
+	}
+}
+
+// Запись отладочного уровня
+func (l *logger) Debug(msg string, keyvals ...any) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+// Запись информационного уровня
+func (l *logger) Info(msg string, keyvals ...any) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+// Запись уровня предупреждения
+func (l *logger) Warn(msg string, keyvals ...any) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+// Запись уровня ошибки
+func (l *logger) Error(msg string, keyvals ...any) {
+	l.log(LevelError, msg, keyvals)
+}
+
+// Производный логгер, добавляющий поля keyvals в каждую запись
+func (l *logger) With(keyvals ...any) ILogger {
+	fields := make([]any, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+
+	return &logger{
+		out:    l.out,
+		level:  l.level,
+		fields: fields,
+	}
+}
+
+// Формирование и вывод записи
+func (l *logger) log(level Level, msg string, keyvals []any) {
+	if level < l.level {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	writeValue(&buf, time.Now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeValue(&buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeValue(&buf, msg)
+	writeFields(&buf, l.fields)
+	writeFields(&buf, keyvals)
+	buf.WriteString("}\n")
+
+	l.out.mtx.Lock()
+	defer l.out.mtx.Unlock()
+	l.out.w.Write(buf.Bytes())
+}
+
+// Вывод пар ключ-значение. Значение без пары выводится под ключом "!BADKEY"
+func writeFields(buf *bytes.Buffer, keyvals []any) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok || i+1 == len(keyvals) {
+			buf.WriteByte(',')
+			writeValue(buf, "!BADKEY")
+			buf.WriteByte(':')
+			writeValue(buf, keyvals[i])
+			i--
+			continue
+		}
+
+		buf.WriteByte(',')
+		writeValue(buf, key)
+		buf.WriteByte(':')
+		writeValue(buf, keyvals[i+1])
+	}
+}
+
+// Вывод значения в JSON. Ошибки и значения, не сериализуемые в JSON, выводятся строкой
+func writeValue(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case time.Time:
+		// Время сериализуется в RFC 3339 самим JSON
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
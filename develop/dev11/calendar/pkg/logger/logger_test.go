@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_logger(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, LevelInfo).With("request_id", "abc")
+
+	log.Debug("skipped")
+	log.Error("failed", "err", errors.New("boom"), "latency", time.Second, "status", 500, "odd")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"level":      "error",
+		"msg":        "failed",
+		"request_id": "abc",
+		"err":        "boom",
+		"latency":    "1s",
+		"status":     float64(500),
+		"!BADKEY":    "odd",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Errorf("expected time field in %v", entry)
+	}
+}
+
+func Test_ParseLevel(t *testing.T) {
+	if level, err := ParseLevel("WARN"); err != nil || level != LevelWarn {
+		t.Errorf("expected warn level, got %v, %v", level, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
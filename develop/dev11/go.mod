@@ -1,3 +1,7 @@
 module dev11
 
 go 1.20
+
+require go.etcd.io/bbolt v1.3.5
+
+require golang.org/x/sys v0.10.0 // indirect
@@ -1,6 +1,10 @@
 package main
 
-import calendar "dev11/calendar/cmd"
+import (
+	calendar "dev11/calendar/cmd"
+	"fmt"
+	"os"
+)
 
 /*
 === HTTP server ===
@@ -27,5 +31,8 @@ import calendar "dev11/calendar/cmd"
 */
 
 func main() {
-	calendar.Start()
+	if err := calendar.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }